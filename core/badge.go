@@ -0,0 +1,110 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ShieldsEndpoint is the shields.io endpoint badge schema
+// (https://shields.io/badges/endpoint-badge).
+type ShieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// badgeHex maps shields color names to the hex values used in rendered SVGs.
+var badgeHex = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+}
+
+// CountTagged counts comments whose tag is in the comma-separated tags list.
+// An empty list counts every comment.
+func CountTagged(all []Comment, tags string) int {
+	if strings.TrimSpace(tags) == "" {
+		return len(all)
+	}
+	set := make(map[string]struct{})
+	for t := range parseTags(tags) {
+		set[canonicalTag(t)] = struct{}{} // comments are stored under canonical tags
+	}
+	n := 0
+	for _, c := range all {
+		if _, ok := set[c.Tag]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+// BadgeColor grades a debt count from brightgreen (none) to red (over 100).
+func BadgeColor(count int) string {
+	switch {
+	case count == 0:
+		return "brightgreen"
+	case count <= 10:
+		return "green"
+	case count <= 50:
+		return "yellow"
+	case count <= 100:
+		return "orange"
+	default:
+		return "red"
+	}
+}
+
+// NewShieldsEndpoint builds the shields.io JSON payload for a count.
+func NewShieldsEndpoint(label string, count int) ShieldsEndpoint {
+	return ShieldsEndpoint{
+		SchemaVersion: 1,
+		Label:         label,
+		Message:       fmt.Sprint(count),
+		Color:         BadgeColor(count),
+	}
+}
+
+// MarshalShieldsEndpoint encodes the shields.io JSON payload for a count.
+func MarshalShieldsEndpoint(label string, count int) ([]byte, error) {
+	data, err := json.MarshalIndent(NewShieldsEndpoint(label, count), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// RenderBadgeSVG draws a flat, shields-style badge: grey label, colored count.
+func RenderBadgeSVG(label string, count int) []byte {
+	e := NewShieldsEndpoint(label, count)
+
+	// Verdana 11px averages roughly 7px per character; pad 10px each side
+	labelW := 7*len(e.Label) + 10
+	msgW := 7*len(e.Message) + 10
+	total := labelW + msgW
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+"\n",
+		total, html.EscapeString(e.Label), e.Message)
+	fmt.Fprintf(&b, `  <title>%s: %s</title>`+"\n", html.EscapeString(e.Label), e.Message)
+	b.WriteString(`  <linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` + "\n")
+	fmt.Fprintf(&b, `  <clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`+"\n", total)
+	b.WriteString(`  <g clip-path="url(#r)">` + "\n")
+	fmt.Fprintf(&b, `    <rect width="%d" height="20" fill="#555"/>`+"\n", labelW)
+	fmt.Fprintf(&b, `    <rect x="%d" width="%d" height="20" fill="%s"/>`+"\n", labelW, msgW, badgeHex[e.Color])
+	fmt.Fprintf(&b, `    <rect width="%d" height="20" fill="url(#s)"/>`+"\n", total)
+	b.WriteString(`  </g>` + "\n")
+	b.WriteString(`  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` + "\n")
+	fmt.Fprintf(&b, `    <text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>`+"\n", labelW/2, html.EscapeString(e.Label))
+	fmt.Fprintf(&b, `    <text x="%d" y="14">%s</text>`+"\n", labelW/2, html.EscapeString(e.Label))
+	fmt.Fprintf(&b, `    <text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>`+"\n", labelW+msgW/2, e.Message)
+	fmt.Fprintf(&b, `    <text x="%d" y="14">%s</text>`+"\n", labelW+msgW/2, e.Message)
+	b.WriteString(`  </g>` + "\n")
+	b.WriteString(`</svg>` + "\n")
+	return []byte(b.String())
+}
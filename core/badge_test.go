@@ -0,0 +1,90 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBadgeColorThresholds(t *testing.T) {
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{0, "brightgreen"},
+		{1, "green"},
+		{10, "green"},
+		{11, "yellow"},
+		{50, "yellow"},
+		{51, "orange"},
+		{100, "orange"},
+		{101, "red"},
+	}
+	for _, tt := range tests {
+		if got := BadgeColor(tt.count); got != tt.want {
+			t.Errorf("BadgeColor(%d) = %q, want %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestCountTagged(t *testing.T) {
+	SetTagAliases(map[string]string{"TBD": "TODO"})
+	defer SetTagAliases(nil)
+
+	all := []Comment{{Tag: "TODO"}, {Tag: "TODO"}, {Tag: "FIXME"}, {Tag: "NOTE"}}
+	tests := []struct {
+		tags string
+		want int
+	}{
+		{"", 4},
+		{"todo", 2},
+		{"TBD", 2}, // comments are stored under the canonical tag
+		{"TODO, FIXME", 3},
+		{"HACK", 0},
+	}
+	for _, tt := range tests {
+		if got := CountTagged(all, tt.tags); got != tt.want {
+			t.Errorf("CountTagged(%q) = %d, want %d", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBadgeSVGEscapesLabel(t *testing.T) {
+	svg := string(RenderBadgeSVG(`R&D <debt> "x"`, 7))
+	if strings.Contains(svg, "R&D") || strings.Contains(svg, "<debt>") {
+		t.Errorf("label not escaped:\n%s", svg)
+	}
+	if !strings.Contains(svg, "R&amp;D &lt;debt&gt; &#34;x&#34;") {
+		t.Errorf("escaped label missing:\n%s", svg)
+	}
+	if !strings.Contains(svg, badgeHex["green"]) {
+		t.Errorf("count 7 should be green:\n%s", svg)
+	}
+}
+
+func TestScanRootsWithoutBlameSkipsBlame(t *testing.T) {
+	dir := t.TempDir()
+	git := gitIn(t, dir)
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("// TODO one\n// FIXME two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "todos")
+
+	stream, errc := ScanRootsWithoutBlame([]string{dir}, 1, "TODO", true)
+	var all []Comment
+	for r := range stream {
+		all = append(all, r.Comments...)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Tag != "TODO" || all[0].ID == "" || all[0].Root != dir {
+		t.Fatalf("comments = %+v", all)
+	}
+	if c := all[0]; c.Commit != "" || c.Author != "" || c.CreationStamp != "" {
+		t.Errorf("committed comment was blamed: %+v", c)
+	}
+}
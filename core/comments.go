@@ -3,13 +3,16 @@ package core
 // Comment represents one tagged comment (TODO/FIXME/etc.) found in a source file.
 // It keeps the tag, the comment content, its location, and Git blame metadata.
 type Comment struct {
-	Tag           string `json:"tag" yaml:"tag"`         // The tag (TODO, FIXME, etc.)
-	Content       string `json:"content" yaml:"content"` // The full comment text
-	FilePath      string `json:"file" yaml:"file"`       // Path to the file containing this comment
-	LineNumber    int    `json:"line" yaml:"line"`       // Line number in the file
-	CreationStamp string `json:"stamp" yaml:"stamp"`     // RFC3339 timestamp from Git blame
-	Author        string `json:"author" yaml:"author"`   // Author of the commit that introduced this line
-	Commit        string `json:"commit" yaml:"commit"`   // Commit hash from Git blame
+	ID            string `json:"id,omitempty" yaml:"id,omitempty"`         // Short stable hash of file, line and content
+	Tag           string `json:"tag" yaml:"tag"`                           // The tag (TODO, FIXME, etc.)
+	Content       string `json:"content" yaml:"content"`                   // The full comment text
+	FilePath      string `json:"file" yaml:"file"`                         // Path to the file containing this comment
+	LineNumber    int    `json:"line" yaml:"line"`                         // Line number in the file
+	CreationStamp string `json:"stamp" yaml:"stamp"`                       // RFC3339 timestamp from Git blame
+	Author        string `json:"author" yaml:"author"`                     // Author of the commit that introduced this line
+	Commit        string `json:"commit" yaml:"commit"`                     // Commit hash from Git blame
+	Module        string `json:"module,omitempty" yaml:"module,omitempty"` // Workspace module containing the file (monorepos)
+	Root          string `json:"root,omitempty" yaml:"root,omitempty"`     // Scan root the file was found under
 }
 
 var (
@@ -1,11 +1,21 @@
 package core
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"sync"
 )
 
+// FileResult holds the comments extracted from one scanned file.
+// Comments is empty for files without tagged comments.
+type FileResult struct {
+	Root     string // scan root the file was found under (set by ScanRoots)
+	Path     string
+	Comments []Comment
+}
+
 // RunExtractCommentsConcurrently processes multiple files in parallel.
 // Uses worker goroutines to avoid bottlenecks on large repos.
 func RunExtractCommentsConcurrently(
@@ -15,32 +25,71 @@ func RunExtractCommentsConcurrently(
 	if len(files) == 0 {
 		return results
 	}
+	if maxWorkers > len(files) {
+		maxWorkers = len(files) // don’t spawn more workers than files
+	}
+
+	paths := make(chan string)
+	go func() {
+		for _, f := range files {
+			paths <- f
+		}
+		close(paths)
+	}()
+
+	for r := range StreamExtractComments(paths, maxWorkers, tags, ignoreErrors) {
+		if len(r.Comments) > 0 {
+			results[r.Path] = r.Comments
+		}
+	}
+	return results
+}
+
+// StreamExtractComments starts a worker pool that consumes file paths until paths
+// is closed and emits one FileResult per file. The returned channel is closed
+// once every worker is done. Its buffer is only as large as the pool, so a slow
+// consumer applies backpressure all the way back to the producer of paths.
+func StreamExtractComments(
+	paths <-chan string, maxWorkers int, tags string, ignoreErrors bool,
+) <-chan FileResult {
+	jobs := make(chan scanJob)
+	go func() {
+		for p := range paths {
+			jobs <- scanJob{path: p}
+		}
+		close(jobs)
+	}()
+	return extractWorkers(jobs, maxWorkers, tags, ignoreErrors, true)
+}
 
+// scanJob is one file to scan together with the root it was found under.
+type scanJob struct {
+	root string
+	path string
+}
+
+// extractWorkers is the worker pool behind StreamExtractComments and ScanRoots.
+// blame controls whether comments get git blame metadata.
+func extractWorkers(
+	jobs <-chan scanJob, maxWorkers int, tags string, ignoreErrors, blame bool,
+) <-chan FileResult {
 	// Default worker count to CPU cores
 	if maxWorkers <= 0 {
 		maxWorkers = runtime.NumCPU()
 	}
-	if maxWorkers > len(files) {
-		maxWorkers = len(files) // don’t spawn more workers than files
-	}
 
-	var mu sync.Mutex
+	out := make(chan FileResult, maxWorkers)
 	var wg sync.WaitGroup
-	ch := make(chan string, len(files)) // buffered channel holds all files
 
-	// Worker: consumes file paths, extracts comments, stores them in results
+	// Worker: consumes file paths, extracts comments, forwards them downstream
 	worker := func() {
 		defer wg.Done()
-		for file := range ch {
-			cmts, err := ExtractComments(file, tags)
+		for job := range jobs {
+			cmts, err := extractComments(job.path, job.root, tags, blame)
 			if err != nil && !ignoreErrors {
-				fmt.Printf("Error processing %s: %v\n", file, err)
-			}
-			if len(cmts) > 0 {
-				mu.Lock()
-				results[file] = cmts
-				mu.Unlock()
+				fmt.Printf("Error processing %s: %v\n", job.path, err)
 			}
+			out <- FileResult{Root: job.root, Path: job.path, Comments: cmts}
 		}
 	}
 
@@ -50,13 +99,79 @@ func RunExtractCommentsConcurrently(
 		go worker()
 	}
 
-	// Send all files into channel
-	for _, f := range files {
-		ch <- f
-	}
-	close(ch)
-	wg.Wait()
+	// Close output once all workers have drained the input
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
 
-	return results
+	return out
+}
+
+// ScanRoots walks several roots concurrently into one shared worker pool and
+// tags every result with the root it came from. When roots are nested, each
+// file belongs to the deepest root containing it: a walker never descends into
+// another root's directory. Roots must be distinct. The error channel receives
+// the combined walk errors; read it after draining the results.
+func ScanRoots(
+	roots []string, maxWorkers int, tags string, ignoreErrors bool,
+) (<-chan FileResult, <-chan error) {
+	return scanRoots(roots, maxWorkers, tags, ignoreErrors, true)
 }
 
+// ScanRootsWithoutBlame is ScanRoots without git blame metadata. Blame forks one
+// git process per tagged comment, so skip it when only counts are needed.
+func ScanRootsWithoutBlame(
+	roots []string, maxWorkers int, tags string, ignoreErrors bool,
+) (<-chan FileResult, <-chan error) {
+	return scanRoots(roots, maxWorkers, tags, ignoreErrors, false)
+}
+
+// scanRoots implements ScanRoots, with or without blame.
+func scanRoots(
+	roots []string, maxWorkers int, tags string, ignoreErrors, blame bool,
+) (<-chan FileResult, <-chan error) {
+	if maxWorkers <= 0 {
+		maxWorkers = runtime.NumCPU()
+	}
+
+	// Absolute form of every root, to recognise nested roots while walking
+	absRoots := make(map[string]struct{}, len(roots))
+	for _, root := range roots {
+		if abs, err := filepath.Abs(root); err == nil {
+			absRoots[abs] = struct{}{}
+		}
+	}
+
+	jobs := make(chan scanJob, maxWorkers)
+	var walkers sync.WaitGroup
+	walkErrs := make([]error, len(roots))
+	for i, root := range roots {
+		walkers.Add(1)
+		go func() {
+			defer walkers.Done()
+			isOtherRoot := func(dir string) bool {
+				abs, err := filepath.Abs(dir)
+				if err != nil {
+					return false
+				}
+				_, ok := absRoots[abs]
+				return ok
+			}
+			err := walkSupportedFiles(root, isOtherRoot, func(path string) {
+				jobs <- scanJob{root: root, path: path}
+			})
+			if err != nil {
+				walkErrs[i] = fmt.Errorf("%s: %w", root, err)
+			}
+		}()
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		walkers.Wait()
+		close(jobs)
+		errc <- errors.Join(walkErrs...)
+	}()
+	return extractWorkers(jobs, maxWorkers, tags, ignoreErrors, blame), errc
+}
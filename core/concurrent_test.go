@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanRootsAttributesNestedRootsDeterministically(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 15; i++ {
+		for _, d := range []string{dir, sub} {
+			path := filepath.Join(d, fmt.Sprintf("f%d.go", i))
+			if err := os.WriteFile(path, []byte("// TODO item\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Run several times: attribution must not depend on walker timing
+	for run := 0; run < 5; run++ {
+		stream, errc := ScanRoots([]string{dir, sub}, 4, "", true)
+		counts := make(map[string]int)
+		for r := range stream {
+			want := dir
+			if filepath.Dir(r.Path) == sub {
+				want = sub
+			}
+			if r.Root != want {
+				t.Fatalf("%s attributed to %s, want %s", r.Path, r.Root, want)
+			}
+			counts[r.Root]++
+		}
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+		if counts[dir] != 15 || counts[sub] != 15 {
+			t.Fatalf("run %d: files per root = %v, want 15 each", run, counts)
+		}
+	}
+}
+
+func TestScanRootsReportsWalkErrors(t *testing.T) {
+	stream, errc := ScanRoots([]string{filepath.Join(t.TempDir(), "missing")}, 2, "", true)
+	for range stream {
+	}
+	if err := <-errc; err == nil {
+		t.Fatal("want error for missing root")
+	}
+}
+
+func TestScanRootsDefaultsNonPositiveWorkers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{0, -3} {
+		stream, errc := ScanRoots([]string{dir}, workers, "", true)
+		n := 0
+		for range stream {
+			n++
+		}
+		if err := <-errc; err != nil || n != 1 {
+			t.Errorf("workers=%d: scanned %d files, err %v", workers, n, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the optional per-project configuration, relative to the project root.
+const ConfigFile = ".tdl/config.yaml"
+
+// Config holds project settings read from ConfigFile.
+//
+//	aliases:
+//	  XXX: HACK
+//	  TBD: TODO
+type Config struct {
+	Aliases map[string]string `yaml:"aliases"` // alias tag -> canonical tag
+}
+
+// LoadConfig reads a YAML config file. A missing file yields an empty Config.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DaemonSocket is the unix socket `tdl daemon` listens on, relative to the project root.
+const DaemonSocket = ".tdl/daemon.sock"
+
+// DaemonRequest is one query sent by a client, encoded as a single JSON line.
+type DaemonRequest struct {
+	Command string `json:"command"` // "comments" or "ping"
+}
+
+// DaemonResponse answers a DaemonRequest. Root and Tags describe what the
+// daemon indexes, so clients can tell whether its answer fits their query.
+type DaemonResponse struct {
+	Root     string    `json:"root"`
+	Tags     string    `json:"tags"`
+	Comments []Comment `json:"comments,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// indexedFile remembers what a file looked like when it was last parsed.
+type indexedFile struct {
+	modTime  time.Time
+	size     int64
+	comments []Comment
+}
+
+// Index keeps every tagged comment under a root in memory and can refresh
+// itself incrementally by re-parsing only files that changed on disk.
+type Index struct {
+	mu        sync.RWMutex
+	root      string
+	tags      string
+	workers   int
+	workspace *Workspace
+	files     map[string]indexedFile
+	head      string // git HEAD at the last refresh
+}
+
+// NewIndex creates an empty index for root. Call Refresh to populate it.
+func NewIndex(root, tags string, workers int) (*Index, error) {
+	ws, err := DetectWorkspace(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Index{
+		root:      root,
+		tags:      tags,
+		workers:   workers,
+		workspace: ws,
+		files:     make(map[string]indexedFile),
+	}, nil
+}
+
+// Refresh walks the tree, re-parses new or modified files and drops deleted ones.
+// When git HEAD moved since the last refresh, files with uncommitted comments
+// are re-parsed too, so their blame picks up the new commit.
+// It returns how many files were re-parsed or removed.
+func (idx *Index) Refresh() (int, error) {
+	head := gitHead(idx.root)
+	idx.mu.RLock()
+	known := make(map[string]indexedFile, len(idx.files))
+	for p, f := range idx.files {
+		known[p] = f
+	}
+	headMoved := head != idx.head
+	idx.mu.RUnlock()
+
+	seen := make(map[string]struct{}, len(known))
+	stats := make(map[string]fs.FileInfo)
+	err := filepath.WalkDir(idx.root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			// Never index our own state directory
+			if d.Name() == ".tdl" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hasSupportedExtension(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // vanished mid-walk; next refresh will notice
+		}
+		seen[path] = struct{}{}
+		if old, ok := known[path]; ok && old.modTime.Equal(info.ModTime()) && old.size == info.Size() &&
+			!(headMoved && old.hasUncommitted()) {
+			return nil // unchanged since last parse
+		}
+		if isBinaryFile(path) {
+			return nil
+		}
+		stats[path] = info
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Re-parse changed files through the regular worker pool; IDs match tdl scan's
+	jobs := make(chan scanJob)
+	go func() {
+		for p := range stats {
+			jobs <- scanJob{root: idx.root, path: p}
+		}
+		close(jobs)
+	}()
+	parsed := make(map[string]indexedFile, len(stats))
+	for r := range extractWorkers(jobs, idx.workers, idx.tags, true, true) {
+		module := idx.workspace.ModuleFor(r.Path)
+		for i := range r.Comments {
+			r.Comments[i].Module = module
+		}
+		info := stats[r.Path]
+		parsed[r.Path] = indexedFile{modTime: info.ModTime(), size: info.Size(), comments: r.Comments}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.head = head
+	changed := len(parsed)
+	for p, f := range parsed {
+		idx.files[p] = f
+	}
+	for p := range idx.files {
+		if _, ok := seen[p]; !ok {
+			delete(idx.files, p)
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// hasUncommitted reports whether any comment in f was blamed on no commit.
+func (f indexedFile) hasUncommitted() bool {
+	for _, c := range f.comments {
+		if isUncommitted(c.Commit) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch refreshes the index every interval until stop is closed.
+func (idx *Index) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if n, err := idx.Refresh(); err != nil {
+				fmt.Println("Error refreshing index:", err)
+			} else if n > 0 {
+				fmt.Printf("Re-indexed %d changed files\n", n)
+			}
+		}
+	}
+}
+
+// Comments returns a snapshot of every indexed comment, sorted by file and line.
+func (idx *Index) Comments() []Comment {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var out []Comment
+	for _, f := range idx.files {
+		out = append(out, f.comments...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FilePath != out[j].FilePath {
+			return out[i].FilePath < out[j].FilePath
+		}
+		return out[i].LineNumber < out[j].LineNumber
+	})
+	return out
+}
+
+// Stats returns the number of indexed files and comments.
+func (idx *Index) Stats() (files, comments int) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for _, f := range idx.files {
+		comments += len(f.comments)
+	}
+	return len(idx.files), comments
+}
+
+// ServeIndex answers DaemonRequests on ln until the listener is closed.
+func ServeIndex(ln net.Listener, idx *Index) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go handleDaemonConn(conn, idx)
+	}
+}
+
+// handleDaemonConn serves a single request/response exchange.
+func handleDaemonConn(conn net.Conn, idx *Index) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req DaemonRequest
+	resp := DaemonResponse{Root: idx.root, Tags: idx.tags}
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		resp.Error = fmt.Sprintf("bad request: %v", err)
+	} else {
+		switch req.Command {
+		case "comments":
+			resp.Comments = idx.Comments()
+		case "ping":
+		default:
+			resp.Error = fmt.Sprintf("unknown command: %s", req.Command)
+		}
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// QueryDaemon asks a running daemon for its indexed comments. The response
+// also carries the daemon's root and tag filter. It fails fast when no daemon is listening.
+func QueryDaemon(socket string) (*DaemonResponse, error) {
+	return sendDaemonRequest(socket, DaemonRequest{Command: "comments"})
+}
+
+// DaemonRunning reports whether a daemon answers on socket.
+func DaemonRunning(socket string) bool {
+	_, err := sendDaemonRequest(socket, DaemonRequest{Command: "ping"})
+	return err == nil
+}
+
+// sendDaemonRequest performs one round trip with the daemon.
+func sendDaemonRequest(socket string, req DaemonRequest) (*DaemonResponse, error) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", socket, 200*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp DaemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// indexedContents lists "file:content" for every comment in idx, in order.
+func indexedContents(idx *Index) []string {
+	var out []string
+	for _, c := range idx.Comments() {
+		out = append(out, filepath.Base(c.FilePath)+":"+c.Content)
+	}
+	return out
+}
+
+func TestIndexRefresh(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.go"), "// TODO first\n")
+	writeFile(t, filepath.Join(root, "b.go"), "// FIXME gone soon\n")
+	writeFile(t, filepath.Join(root, "notes.txt"), "TODO not a supported file\n")
+
+	idx, err := NewIndex(root, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := idx.Refresh(); err != nil || n != 2 {
+		t.Fatalf("initial Refresh = %d, %v; want 2 files", n, err)
+	}
+	if got, want := indexedContents(idx), []string{"a.go:TODO first", "b.go:FIXME gone soon"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Comments = %v, want %v", got, want)
+	}
+	if n, _ := idx.Refresh(); n != 0 {
+		t.Errorf("Refresh without changes re-parsed %d files", n)
+	}
+
+	// Modify one file (the size changes too, so coarse mtimes cannot hide it),
+	// delete another and add a third
+	writeFile(t, filepath.Join(root, "a.go"), "// TODO first, reworded\n// NOTE added\n")
+	if err := os.Remove(filepath.Join(root, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "c.go"), "// HACK new file\n")
+
+	if n, err := idx.Refresh(); err != nil || n != 3 {
+		t.Fatalf("Refresh after changes = %d, %v; want 3", n, err)
+	}
+	want := []string{"a.go:TODO first, reworded", "a.go:NOTE added", "c.go:HACK new file"}
+	if got := indexedContents(idx); !reflect.DeepEqual(got, want) {
+		t.Errorf("Comments = %v, want %v", got, want)
+	}
+	if files, comments := idx.Stats(); files != 2 || comments != 3 {
+		t.Errorf("Stats = %d files, %d comments; want 2, 3", files, comments)
+	}
+	for _, c := range idx.Comments() {
+		if c.Root != root {
+			t.Errorf("%s: Root = %q, want %q", c.FilePath, c.Root, root)
+		}
+	}
+}
+
+// gitIn returns a helper running git in root as author "Tess",
+// skipping the test when git is not installed.
+func gitIn(t *testing.T, root string) func(args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	return func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Tess", "GIT_AUTHOR_EMAIL=tess@example.com",
+			"GIT_COMMITTER_NAME=Tess", "GIT_COMMITTER_EMAIL=tess@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestIndexRefreshUpdatesBlameAfterCommit(t *testing.T) {
+	root := t.TempDir()
+	git := gitIn(t, root)
+	git("init", "-q")
+	writeFile(t, filepath.Join(root, "base.go"), "package a\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+
+	writeFile(t, filepath.Join(root, "a.go"), "// TODO blame me\n")
+	idx, err := NewIndex(root, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if c := idx.Comments()[0]; !isUncommitted(c.Commit) {
+		t.Fatalf("untracked comment blamed on %q", c.Commit)
+	}
+
+	// Committing moves HEAD without touching the file
+	git("add", "a.go")
+	git("commit", "-q", "-m", "add todo")
+	if n, err := idx.Refresh(); err != nil || n != 1 {
+		t.Fatalf("Refresh after commit = %d, %v; want 1", n, err)
+	}
+	if c := idx.Comments()[0]; isUncommitted(c.Commit) || c.Author != "Tess" {
+		t.Errorf("blame not refreshed: commit %q, author %q", c.Commit, c.Author)
+	}
+}
+
+func TestServeIndexQueryDaemon(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.go"), "// TODO answer over the socket\n// NOTE filtered out\n")
+	idx, err := NewIndex(root, "TODO", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "d.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- ServeIndex(ln, idx) }()
+
+	if !DaemonRunning(socket) {
+		t.Fatal("DaemonRunning = false while serving")
+	}
+	resp, err := QueryDaemon(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Root != root || resp.Tags != "TODO" {
+		t.Errorf("response root %q tags %q, want %q TODO", resp.Root, resp.Tags, root)
+	}
+	if len(resp.Comments) != 1 || resp.Comments[0].Content != "TODO answer over the socket" {
+		t.Errorf("Comments = %+v", resp.Comments)
+	}
+	if _, err := sendDaemonRequest(socket, DaemonRequest{Command: "bogus"}); err == nil {
+		t.Error("unknown command did not fail")
+	}
+
+	ln.Close()
+	if err := <-served; err != nil {
+		t.Errorf("ServeIndex returned %v after close", err)
+	}
+	if DaemonRunning(socket) {
+		t.Error("DaemonRunning = true after the listener closed")
+	}
+}
@@ -23,24 +23,50 @@ func isBinaryFile(path string) bool {
 // GetAllFilePaths walks a directory tree and returns all supported text files.
 func GetAllFilePaths(root string) ([]string, error) {
 	var out []string
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
+	err := walkSupportedFiles(root, nil, func(path string) {
+		out = append(out, path)
+	})
+	return out, err
+}
+
+// hasSupportedExtension reports whether the file's extension (or basename,
+// e.g. Makefile) maps to a known comment delimiter.
+func hasSupportedExtension(path string) bool {
+	_, ok := commentDelimiter(path)
+	return ok
+}
+
+// walkSupportedFiles calls fn for every supported, non-binary file under root.
+// Directories (other than root) for which skipDir returns true are not entered.
+func walkSupportedFiles(root string, skipDir func(path string) bool, fn func(path string)) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
 		if d.IsDir() {
+			if skipDir != nil && path != root && skipDir(path) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == "" {
-			ext = strings.ToLower(filepath.Base(path))
-		}
 		// Skip unsupported or binary files
-		if _, ok := extensionToChar[ext]; !ok || isBinaryFile(path) {
+		if !hasSupportedExtension(path) || isBinaryFile(path) {
 			return nil
 		}
-		out = append(out, path)
+		fn(path)
 		return nil
 	})
-	return out, err
 }
 
+// pathUnderRoot strips the scan root from path, so "../app/core/a.go" scanned
+// from root "../app" becomes "core/a.go". Paths outside root are returned as is.
+func pathUnderRoot(path, root string) string {
+	if root == "" {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -10,9 +11,11 @@ import (
 
 // fetchGitBlameInfo gets commit, author, and timestamp for a given file+line.
 // It runs: git blame -L <line>,<line> --porcelain -- <file>
+// from the file's own directory, so files under roots in other repositories are blamed too.
 func fetchGitBlameInfo(filePath string, line int) (commit, author, stamp string, err error) {
 	cmd := exec.Command("git", "blame", "-L", fmt.Sprintf("%d,%d", line, line),
-		"--porcelain", "--", filePath)
+		"--porcelain", "--", filepath.Base(filePath))
+	cmd.Dir = filepath.Dir(filePath)
 	out, err := cmd.Output()
 	if err != nil {
 		return "", "", "", err
@@ -47,3 +50,18 @@ func fetchGitBlameInfo(filePath string, line int) (commit, author, stamp string,
 	return commit, author, stamp, nil
 }
 
+// gitHead returns the commit HEAD points to in the repository containing dir,
+// or "" when dir is not inside a git repository.
+func gitHead(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// isUncommitted reports whether blame found no commit for a line: either the
+// file is untracked (no blame at all) or the line is not committed yet.
+func isUncommitted(commit string) bool {
+	return strings.Trim(commit, "0") == ""
+}
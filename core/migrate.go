@@ -0,0 +1,121 @@
+package core
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TagRewrite describes one comment line whose alias tag was (or would be) normalized.
+type TagRewrite struct {
+	FilePath   string
+	LineNumber int
+	Before     string
+	After      string
+	Skipped    bool // delimiter does not start the line (may be code or a string), never written
+}
+
+// MigrateTags replaces alias tags (e.g. XXX) with their canonical tag (e.g. HACK)
+// in the comments of filePath. Only the tag a scan would report is rewritten:
+// the first tag in the comment text, and only if it is an alias. Lines where the
+// comment delimiter does not start the line could be code (`"http://host/TBD"`),
+// so they are returned with Skipped set and never written.
+// When write is false the file is left as is and the planned rewrites are returned.
+func MigrateTags(filePath string, aliases map[string]string, write bool) ([]TagRewrite, error) {
+	char, ok := commentDelimiter(filePath)
+	if !ok || len(aliases) == 0 {
+		return nil, nil
+	}
+
+	canonical := make(map[string]string, len(aliases))
+	for alias, canon := range aliases {
+		alias = strings.ToUpper(strings.TrimSpace(alias))
+		canon = strings.ToUpper(strings.TrimSpace(canon))
+		if alias != "" && canon != "" && alias != canon {
+			canonical[alias] = canon
+		}
+	}
+	if len(canonical) == 0 {
+		return nil, nil
+	}
+	re := migrationMatcher(canonical)
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// SplitAfter keeps line endings, so unchanged lines are written back byte for byte
+	lines := strings.SplitAfter(string(data), "\n")
+	var out []TagRewrite
+	changed := false
+	for i, line := range lines {
+		pos := strings.Index(line, char)
+		if pos == -1 {
+			continue
+		}
+		start := pos + len(char)
+		comment := line[start:]
+
+		loc := re.FindStringIndex(comment)
+		if loc == nil {
+			continue
+		}
+		canon, isAlias := canonical[strings.ToUpper(comment[loc[0]:loc[1]])]
+		if !isAlias {
+			continue // first tag is already canonical
+		}
+
+		rewritten := line[:start] + comment[:loc[0]] + canon + comment[loc[1]:]
+		skipped := strings.TrimSpace(line[:pos]) != ""
+		if !skipped {
+			lines[i] = rewritten
+			changed = true
+		}
+		out = append(out, TagRewrite{
+			FilePath:   filePath,
+			LineNumber: i + 1,
+			Before:     strings.TrimRight(line, "\r\n"),
+			After:      strings.TrimRight(rewritten, "\r\n"),
+			Skipped:    skipped,
+		})
+	}
+
+	if write && changed {
+		if err := os.WriteFile(filePath, []byte(strings.Join(lines, "")), info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// migrationMatcher matches, case-insensitively like scanning, any supported tag,
+// any alias, or any alias target, so the first match is the tag a scan reports.
+func migrationMatcher(canonical map[string]string) *regexp.Regexp {
+	set := make(map[string]struct{})
+	for _, t := range SupportedTags {
+		set[t] = struct{}{}
+	}
+	for alias, canon := range canonical {
+		set[alias] = struct{}{}
+		set[canon] = struct{}{}
+	}
+
+	alts := make([]string, 0, len(set))
+	for t := range set {
+		alts = append(alts, regexp.QuoteMeta(t))
+	}
+	// Longest first, as in tagMatcher
+	sort.Slice(alts, func(i, j int) bool {
+		if len(alts[i]) != len(alts[j]) {
+			return len(alts[i]) > len(alts[j])
+		}
+		return alts[i] < alts[j]
+	})
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(alts, "|") + `)\b`)
+}
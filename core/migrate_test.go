@@ -0,0 +1,98 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTemp(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestMigrateTagsLeavesStringsAlone(t *testing.T) {
+	src := "package x\n\nvar u = \"http://host/TBD\"\n"
+	path := writeTemp(t, "a.go", src)
+
+	rewrites, err := MigrateTags(path, map[string]string{"TBD": "TODO"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != src {
+		t.Errorf("file changed:\n%s", got)
+	}
+	if len(rewrites) != 1 || !rewrites[0].Skipped {
+		t.Errorf("want one skipped rewrite, got %+v", rewrites)
+	}
+}
+
+func TestMigrateTagsRewritesOnlyTheReportedTag(t *testing.T) {
+	aliases := map[string]string{"XXX": "HACK", "TBD": "TODO"}
+	src := "// TBD: the xxx coordinate is TBD\n" +
+		"// TODO: rename the XXX field\n" +
+		"// plain comment\n" +
+		"\t# not a delimiter for go\n"
+	want := "// TODO: the xxx coordinate is TBD\n" +
+		"// TODO: rename the XXX field\n" +
+		"// plain comment\n" +
+		"\t# not a delimiter for go\n"
+	path := writeTemp(t, "a.go", src)
+
+	rewrites, err := MigrateTags(path, aliases, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(rewrites) != 1 || rewrites[0].LineNumber != 1 {
+		t.Errorf("want one rewrite on line 1, got %+v", rewrites)
+	}
+}
+
+func TestMigrateTagsPreservesCRLF(t *testing.T) {
+	src := "# xxx: windows line\r\nx = 1  # trailing TBD\r\n# done\r\n"
+	want := "# HACK: windows line\r\nx = 1  # trailing TBD\r\n# done\r\n"
+	path := writeTemp(t, "a.py", src)
+
+	rewrites, err := MigrateTags(path, map[string]string{"XXX": "HACK", "TBD": "TODO"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(rewrites) != 2 || rewrites[0].After != "# HACK: windows line" || !rewrites[1].Skipped {
+		t.Errorf("unexpected rewrites %+v", rewrites)
+	}
+}
+
+func TestMigrateTagsDryRun(t *testing.T) {
+	src := "// XXX: later\n"
+	path := writeTemp(t, "a.go", src)
+
+	rewrites, err := MigrateTags(path, map[string]string{"XXX": "HACK"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rewrites) != 1 || rewrites[0].After != "// HACK: later" {
+		t.Errorf("unexpected plan %+v", rewrites)
+	}
+	if got := readFile(t, path); got != src {
+		t.Errorf("dry run modified the file: %q", got)
+	}
+}
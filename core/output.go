@@ -3,71 +3,169 @@ package core
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
 
 // PrepareOutputFile saves results to disk in JSON, YAML, or plain text format.
 func PrepareOutputFile(results map[string][]Comment, format, outputDir string) error {
-	var all []Comment
-	for _, list := range results {
-		all = append(all, list...)
+	w, err := NewCommentWriter(format, outputDir)
+	if err != nil {
+		return err
 	}
-	if len(all) == 0 {
-		return fmt.Errorf("no comments found")
+	for _, list := range results {
+		if err := w.Write(list); err != nil {
+			w.Discard()
+			return err
+		}
 	}
+	return w.Close()
+}
 
+// CommentWriter streams comments to outputDir/comments.<format> as they arrive,
+// so a scan never has to hold every comment in memory before writing.
+// Output goes to a temporary file that replaces the real one on Close.
+type CommentWriter struct {
+	format  string
+	outPath string
+	f       *os.File
+	count   int
+}
+
+// NewCommentWriter creates a writer for the JSON, YAML, or plain text format.
+func NewCommentWriter(format, outputDir string) (*CommentWriter, error) {
 	ext := strings.ToLower(format)
-	fileName := "comments." + ext
-	outPath := filepath.Join(outputDir, fileName)
+	switch ext {
+	case "json", "yaml", "yml", "text", "txt":
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
 
 	// Create output directory if it doesn’t exist
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	f, err := os.Create(outPath)
+	f, err := os.CreateTemp(outputDir, "comments-*.tmp")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
+	return &CommentWriter{
+		format:  ext,
+		outPath: filepath.Join(outputDir, "comments."+ext),
+		f:       f,
+	}, nil
+}
 
-	// Write in requested format
-	switch ext {
+// Write appends comments to the output file.
+func (w *CommentWriter) Write(comments []Comment) error {
+	for _, c := range comments {
+		if err := w.writeOne(c); err != nil {
+			return err
+		}
+		w.count++
+	}
+	return nil
+}
+
+// writeOne encodes a single comment so the finished file matches what
+// encoding the whole slice at once would have produced.
+func (w *CommentWriter) writeOne(c Comment) error {
+	switch w.format {
 	case "json":
-		enc := json.NewEncoder(f)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(all); err != nil {
+		data, err := json.MarshalIndent(c, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		sep := ",\n  "
+		if w.count == 0 {
+			sep = "[\n  "
+		}
+		if _, err := fmt.Fprintf(w.f, "%s%s", sep, data); err != nil {
 			return fmt.Errorf("failed to write JSON: %w", err)
 		}
 	case "yaml", "yml":
-		enc := yaml.NewEncoder(f)
-		defer enc.Close()
-		if err := enc.Encode(all); err != nil {
+		data, err := yaml.Marshal([]Comment{c})
+		if err != nil {
+			return fmt.Errorf("failed to write YAML: %w", err)
+		}
+		if _, err := w.f.Write(data); err != nil {
 			return fmt.Errorf("failed to write YAML: %w", err)
 		}
 	case "text", "txt":
-		for _, c := range all {
-			if _, err := fmt.Fprintf(f, "%s:%d [%s] %s\n",
-				c.FilePath, c.LineNumber, c.Tag, c.Content); err != nil {
-				return fmt.Errorf("failed to write text: %w", err)
-			}
+		if _, err := fmt.Fprintf(w.f, "%s:%d [%s] %s\n",
+			c.FilePath, c.LineNumber, c.Tag, c.Content); err != nil {
+			return fmt.Errorf("failed to write text: %w", err)
+		}
+	}
+	return nil
+}
+
+// Count reports how many comments have been written so far.
+func (w *CommentWriter) Count() int {
+	return w.count
+}
+
+// Close finalizes the output and moves it into place. If no comments were
+// written, any previous output file is left untouched and an error is returned.
+func (w *CommentWriter) Close() error {
+	if w.count == 0 {
+		w.Discard()
+		return fmt.Errorf("no comments found")
+	}
+	if w.format == "json" {
+		if _, err := io.WriteString(w.f, "\n]\n"); err != nil {
+			w.Discard()
+			return fmt.Errorf("failed to write JSON: %w", err)
 		}
-	default:
-		return fmt.Errorf("unsupported output format: %s", format)
+	}
+	if err := w.f.Chmod(0644); err != nil {
+		w.Discard()
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		os.Remove(w.f.Name())
+		return err
+	}
+	if err := os.Rename(w.f.Name(), w.outPath); err != nil {
+		os.Remove(w.f.Name())
+		return err
 	}
 
-	fmt.Printf("Extracted %d comments written to %s\n", len(all), outPath)
+	fmt.Printf("Extracted %d comments written to %s\n", w.count, w.outPath)
 	return nil
 }
 
-// PrettyPrintComments outputs results to stdout with optional ANSI colors.
-func PrettyPrintComments(m map[string][]Comment, color bool) {
-	const reset = "\033[0m"
+// Discard abandons the output, removing the temporary file.
+func (w *CommentWriter) Discard() {
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+// PrintOptions controls which columns PrettyPrintComments shows and how.
+type PrintOptions struct {
+	Color      bool          // ANSI colors
+	ShowID     bool          // comment ID column (for tdl promote)
+	ShowAuthor bool          // blame author column
+	ShowAge    bool          // "14 months old" column
+	ShowCommit bool          // short commit hash column
+	StaleAfter time.Duration // rows older than this are emphasized in color (0 disables)
+}
+
+// PrettyPrintComments outputs results to stdout with optional ANSI colors
+// and blame metadata columns.
+func PrettyPrintComments(m map[string][]Comment, opts PrintOptions) {
+	const (
+		reset = "\033[0m"
+		stale = "\033[1;31m" // bold red
+	)
 	colors := map[string]string{
 		"TODO":      "\033[33m", // yellow
 		"FIXME":     "\033[31m", // red
@@ -79,14 +177,22 @@ func PrettyPrintComments(m map[string][]Comment, color bool) {
 	}
 
 	files := make([]string, 0, len(m))
-	for f := range m {
+	authorWidth, ageWidth := 0, 0
+	now := time.Now()
+	for f, list := range m {
 		files = append(files, f)
+		// Column widths are shared by all files so output lines up
+		for _, c := range list {
+			// fmt pads by runes, so measure in runes too
+			authorWidth = max(authorWidth, utf8.RuneCountInString(displayAuthor(c)))
+			ageWidth = max(ageWidth, utf8.RuneCountInString(FormatAge(c.CreationStamp, now)))
+		}
 	}
 	sort.Strings(files)
 
 	for _, file := range files {
 		list := m[file]
-		if color {
+		if opts.Color {
 			fmt.Printf("\033[36mFile: %s%s\n", file, reset)
 		} else {
 			fmt.Printf("File: %s\n", file)
@@ -99,17 +205,94 @@ func PrettyPrintComments(m map[string][]Comment, color bool) {
 		sort.Slice(list, func(i, j int) bool { return list[i].LineNumber < list[j].LineNumber })
 		for _, c := range list {
 			line := fmt.Sprintf("%-5d", c.LineNumber)
-			if color {
+
+			// Optional columns, in id/commit/author/age order
+			var meta strings.Builder
+			if opts.ShowID {
+				fmt.Fprintf(&meta, "%-8s ", c.ID)
+			}
+			if opts.ShowCommit {
+				fmt.Fprintf(&meta, "%-7s ", shortCommit(c.Commit))
+			}
+			if opts.ShowAuthor {
+				fmt.Fprintf(&meta, "%-*s ", authorWidth, displayAuthor(c))
+			}
+			if opts.ShowAge {
+				fmt.Fprintf(&meta, "%-*s ", ageWidth, FormatAge(c.CreationStamp, now))
+			}
+
+			if opts.Color {
 				col, ok := colors[c.Tag]
 				if !ok {
 					col = reset
 				}
-				fmt.Printf("    %s %s%s%s\n", line, col, c.Content, reset)
+				// Stale rows get their line number and blame columns in bold red,
+				// whichever columns are shown
+				prefix := line + " " + meta.String()
+				if isStale(c.CreationStamp, now, opts.StaleAfter) {
+					prefix = stale + prefix + reset
+				}
+				fmt.Printf("    %s%s%s%s\n", prefix, col, c.Content, reset)
 			} else {
-				fmt.Printf("    %s %s\n", line, c.Content)
+				fmt.Printf("    %s %s%s\n", line, meta.String(), c.Content)
 			}
 		}
 		fmt.Println()
 	}
 }
 
+// FormatAge renders an RFC3339 blame stamp as a human age like "3 days old"
+// or "14 months old". Missing or malformed stamps yield "unknown age".
+func FormatAge(stamp string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, stamp)
+	if err != nil {
+		return "unknown age"
+	}
+	days := int(now.Sub(t).Hours() / 24)
+	switch {
+	case days < 1:
+		return "today"
+	case days < 31:
+		return plural(days, "day") + " old"
+	case days < 2*365:
+		return plural(days/30, "month") + " old"
+	default:
+		return plural(days/365, "year") + " old"
+	}
+}
+
+// isStale reports whether stamp is older than threshold. A zero threshold disables it.
+func isStale(stamp string, now time.Time, threshold time.Duration) bool {
+	if threshold <= 0 {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, stamp)
+	return err == nil && now.Sub(t) > threshold
+}
+
+// plural formats n with unit, adding an "s" unless n is 1.
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// shortCommit trims a blame hash to the usual 7 characters.
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "-"
+	}
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// displayAuthor returns the blame author or a placeholder when blame failed.
+func displayAuthor(c Comment) string {
+	if c.Author == "" {
+		return "unknown"
+	}
+	return c.Author
+}
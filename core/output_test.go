@@ -0,0 +1,182 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func sampleComments(n int) []Comment {
+	out := make([]Comment, n)
+	for i := range out {
+		out[i] = Comment{
+			ID:         fmt.Sprintf("id%d", i),
+			Tag:        "TODO",
+			Content:    fmt.Sprintf(`TODO <fix> "item" & %d`, i),
+			FilePath:   "dir/file.go",
+			LineNumber: i + 1,
+		}
+	}
+	return out
+}
+
+func TestCommentWriterJSON(t *testing.T) {
+	for _, n := range []int{1, 2, 25} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := NewCommentWriter("json", dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := sampleComments(n)
+			// Write in uneven batches, as the scan does per file
+			for i := 0; i < n; i += 2 {
+				if err := w.Write(want[i:min(i+2, n)]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "comments.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []Comment
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("output is not valid JSON: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("decoded %+v, want %+v", got, want)
+			}
+			assertNoTempFiles(t, dir)
+		})
+	}
+}
+
+func TestCommentWriterEmpty(t *testing.T) {
+	dir := t.TempDir()
+	previous := []byte("[]\n")
+	outPath := filepath.Join(dir, "comments.json")
+	if err := os.WriteFile(outPath, previous, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewCommentWriter("json", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err == nil {
+		t.Fatal("Close with no comments: want error")
+	}
+
+	// The previous output must survive an empty scan
+	data, err := os.ReadFile(outPath)
+	if err != nil || string(data) != string(previous) {
+		t.Errorf("previous output changed: %q, %v", data, err)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestCommentWriterRejectsUnknownFormat(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewCommentWriter("xml", dir); err == nil {
+		t.Fatal("want error for unsupported format")
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "comments-*.tmp"))
+	if len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestFormatAgeBoundaries(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(days int) string { return now.AddDate(0, 0, -days).Format(time.RFC3339) }
+	tests := []struct {
+		stamp string
+		want  string
+	}{
+		{ago(0), "today"},
+		{ago(1), "1 day old"},
+		{ago(30), "30 days old"},
+		{ago(31), "1 month old"},
+		{ago(729), "24 months old"},
+		{ago(730), "2 years old"},
+		{now.Add(48 * time.Hour).Format(time.RFC3339), "today"}, // clock skew: never negative
+		{"", "unknown age"},
+		{"2024-13-45", "unknown age"},
+	}
+	for _, tt := range tests {
+		if got := FormatAge(tt.stamp, now); got != tt.want {
+			t.Errorf("FormatAge(%q) = %q, want %q", tt.stamp, got, tt.want)
+		}
+	}
+}
+
+// capturePrint returns what PrettyPrintComments writes to stdout.
+func capturePrint(t *testing.T, m map[string][]Comment, opts PrintOptions) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	PrettyPrintComments(m, opts)
+	os.Stdout = stdout
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPrettyPrintAlignsNonASCIIAuthors(t *testing.T) {
+	m := map[string][]Comment{"a.go": {
+		{Tag: "TODO", Content: "TODO one", LineNumber: 1, Author: "Zoë Ångström"},
+		{Tag: "TODO", Content: "TODO two", LineNumber: 2, Author: "bob"},
+	}}
+	out := capturePrint(t, m, PrintOptions{ShowAuthor: true})
+	var cols []int
+	for _, line := range strings.Split(out, "\n") {
+		if i := strings.Index(line, "TODO"); i != -1 {
+			cols = append(cols, utf8.RuneCountInString(line[:i]))
+		}
+	}
+	if len(cols) != 2 || cols[0] != cols[1] {
+		t.Errorf("comment columns start at %v, want equal:\n%s", cols, out)
+	}
+}
+
+func TestPrettyPrintEmphasizesStaleRowsWithoutAge(t *testing.T) {
+	old := time.Now().AddDate(-3, 0, 0).Format(time.RFC3339)
+	fresh := time.Now().Format(time.RFC3339)
+	m := map[string][]Comment{"a.go": {
+		{Tag: "TODO", Content: "TODO old", LineNumber: 1, Author: "ann", CreationStamp: old},
+		{Tag: "TODO", Content: "TODO new", LineNumber: 2, Author: "ann", CreationStamp: fresh},
+	}}
+	out := capturePrint(t, m, PrintOptions{Color: true, ShowAuthor: true, StaleAfter: 365 * 24 * time.Hour})
+	for _, line := range strings.Split(out, "\n") {
+		stale := strings.Contains(line, "\033[1;31m")
+		switch {
+		case strings.Contains(line, "TODO old") && !stale:
+			t.Errorf("stale row not emphasized: %q", line)
+		case strings.Contains(line, "TODO new") && stale:
+			t.Errorf("fresh row emphasized: %q", line)
+		}
+	}
+}
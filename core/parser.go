@@ -2,21 +2,47 @@ package core
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+)
+
+var (
+	// Compiled tag matchers keyed by the raw -tag string, so each distinct
+	// filter is compiled once per process instead of once per matching line.
+	tagMatchers sync.Map // map[string]*regexp.Regexp
+
+	// Alias tag -> canonical tag (e.g. XXX -> HACK), set via SetTagAliases.
+	tagAliases   = make(map[string]string)
+	tagAliasesMu sync.RWMutex
+
+	// Scanner buffers are reused across files to avoid a 64KB allocation per file.
+	scanBufPool = sync.Pool{
+		New: func() any {
+			buf := make([]byte, 64*1024)
+			return &buf
+		},
+	}
 )
 
 // ExtractComments scans one file line by line for tagged comments.
 func ExtractComments(filePath, tags string) ([]Comment, error) {
-	// Resolve extension or basename (e.g. Makefile)
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext == "" {
-		ext = strings.ToLower(filepath.Base(filePath))
-	}
+	return extractComments(filePath, "", tags, true)
+}
 
-	char, ok := extensionToChar[ext]
+// extractComments is ExtractComments for a file found under a scan root.
+// Comments record the root, and their IDs hash the path relative to it, so
+// spelling the root differently (".", "/abs/repo", "../repo") keeps every ID.
+// Without blame, Commit, Author and CreationStamp stay empty and no git
+// process is started.
+func extractComments(filePath, root, tags string, blame bool) ([]Comment, error) {
+	char, ok := commentDelimiter(filePath)
 	if !ok {
 		return nil, nil // unsupported file type
 	}
@@ -27,12 +53,15 @@ func ExtractComments(filePath, tags string) ([]Comment, error) {
 	}
 	defer f.Close()
 
-	tagSet := parseTags(tags)
+	re := tagMatcher(tags)
 	sc := bufio.NewScanner(f)
-	buf := make([]byte, 64*1024)
-	sc.Buffer(buf, maxScanCapacity)
+	buf := scanBufPool.Get().(*[]byte)
+	defer scanBufPool.Put(buf)
+	sc.Buffer(*buf, maxScanCapacity)
 
+	idPath := pathUnderRoot(filePath, root)
 	var out []Comment
+	occurrences := make(map[string]int) // identical comment text seen so far, for IDs
 	lineNum := 0
 	for sc.Scan() {
 		lineNum++
@@ -44,9 +73,15 @@ func ExtractComments(filePath, tags string) ([]Comment, error) {
 		text := strings.TrimSpace(line[pos+len(char):])
 
 		// If the comment contains a supported tag, capture it
-		if tag := findTag(text, tagSet); tag != "" {
-			commit, author, stamp, _ := fetchGitBlameInfo(filePath, lineNum)
+		if tag := findTag(text, re); tag != "" {
+			var commit, author, stamp string
+			if blame {
+				commit, author, stamp, _ = fetchGitBlameInfo(filePath, lineNum)
+			}
+			occurrence := occurrences[text]
+			occurrences[text]++
 			out = append(out, Comment{
+				ID:            commentID(idPath, text, occurrence),
 				Tag:           tag,
 				Content:       text,
 				FilePath:      filePath,
@@ -54,6 +89,7 @@ func ExtractComments(filePath, tags string) ([]Comment, error) {
 				Commit:        commit,
 				Author:        author,
 				CreationStamp: stamp,
+				Root:          root,
 			})
 		}
 	}
@@ -63,6 +99,56 @@ func ExtractComments(filePath, tags string) ([]Comment, error) {
 	return out, nil
 }
 
+// commentID derives a short identifier from a comment's file (relative to its
+// scan root), text and occurrence index (the nth identical comment in that file).
+// Line numbers are not part of it, so adding or removing lines around a comment
+// keeps its ID. Editing the comment, moving it to another file, or inserting an
+// identical comment above it changes the ID.
+func commentID(filePath, text string, occurrence int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", filepath.ToSlash(filePath), text, occurrence)))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// commentDelimiter resolves the single-line comment delimiter for a file
+// from its extension or basename (e.g. Makefile).
+func commentDelimiter(filePath string) (string, bool) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == "" {
+		ext = strings.ToLower(filepath.Base(filePath))
+	}
+	char, ok := extensionToChar[ext]
+	return char, ok
+}
+
+// SetTagAliases registers alias -> canonical tag mappings (e.g. XXX -> HACK).
+// Scanning then matches aliases too but reports them under the canonical tag.
+// Call it before scanning; it replaces any previously registered aliases.
+func SetTagAliases(aliases map[string]string) {
+	normalized := make(map[string]string, len(aliases))
+	for alias, canonical := range aliases {
+		alias = strings.ToUpper(strings.TrimSpace(alias))
+		canonical = strings.ToUpper(strings.TrimSpace(canonical))
+		if alias != "" && canonical != "" && alias != canonical {
+			normalized[alias] = canonical
+		}
+	}
+
+	tagAliasesMu.Lock()
+	tagAliases = normalized
+	tagAliasesMu.Unlock()
+	tagMatchers.Clear() // cached matchers were built without these aliases
+}
+
+// canonicalTag maps an alias to its canonical tag; other tags pass through.
+func canonicalTag(tag string) string {
+	tagAliasesMu.RLock()
+	defer tagAliasesMu.RUnlock()
+	if canonical, ok := tagAliases[tag]; ok {
+		return canonical
+	}
+	return tag
+}
+
 // parseTags converts a comma-separated string into a lookup map of tags.
 func parseTags(tags string) map[string]struct{} {
 	if strings.TrimSpace(tags) == "" {
@@ -85,15 +171,45 @@ func parseTags(tags string) map[string]struct{} {
 	return set
 }
 
-// findTag checks if any supported tag exists in the given text.
-func findTag(text string, tags map[string]struct{}) string {
-	upper := strings.ToUpper(text)
-	for t := range tags {
-		re := regexp.MustCompile(`\b` + regexp.QuoteMeta(t) + `\b`)
-		if re.MatchString(upper) {
-			return t
+// tagMatcher returns a single regexp matching any tag in the comma-separated list.
+// Results are cached, so repeated calls with the same filter are cheap.
+func tagMatcher(tags string) *regexp.Regexp {
+	if re, ok := tagMatchers.Load(tags); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	set := parseTags(tags)
+	// Aliases of any requested tag are matched as well
+	tagAliasesMu.RLock()
+	for alias, canonical := range tagAliases {
+		if _, ok := set[canonical]; ok {
+			set[alias] = struct{}{}
 		}
 	}
+	tagAliasesMu.RUnlock()
+
+	alts := make([]string, 0, len(set))
+	for t := range set {
+		alts = append(alts, regexp.QuoteMeta(t))
+	}
+	// Longest first so a tag never loses to one of its own prefixes
+	sort.Slice(alts, func(i, j int) bool {
+		if len(alts[i]) != len(alts[j]) {
+			return len(alts[i]) > len(alts[j])
+		}
+		return alts[i] < alts[j]
+	})
+
+	re := regexp.MustCompile(`\b(?:` + strings.Join(alts, "|") + `)\b`)
+	actual, _ := tagMatchers.LoadOrStore(tags, re)
+	return actual.(*regexp.Regexp)
+}
+
+// findTag returns the canonical form of the first tag matched by re in the given text, or "".
+func findTag(text string, re *regexp.Regexp) string {
+	if tag := re.FindString(strings.ToUpper(text)); tag != "" {
+		return canonicalTag(tag)
+	}
 	return ""
 }
 
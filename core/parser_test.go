@@ -0,0 +1,192 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTagMatcherLeftmostMatch(t *testing.T) {
+	re := tagMatcher("")
+	if got := findTag("FIXME before the TODO", re); got != "FIXME" {
+		t.Errorf("findTag = %q, want FIXME", got)
+	}
+	if got := findTag("see note: todo later", re); got != "NOTE" {
+		t.Errorf("findTag = %q, want NOTE (case-insensitive, leftmost)", got)
+	}
+	if got := findTag("TODOS and NOTED are not tags", re); got != "" {
+		t.Errorf("findTag = %q, want no match on partial words", got)
+	}
+}
+
+func TestTagMatcherPrefersLongerTag(t *testing.T) {
+	// Both alternatives match at the same position; the longer tag must win
+	re := tagMatcher("TODO,TODO-NOW")
+	if got := findTag("TODO-NOW fix the build", re); got != "TODO-NOW" {
+		t.Errorf("findTag = %q, want TODO-NOW", got)
+	}
+	if got := findTag("TODO later", re); got != "TODO" {
+		t.Errorf("findTag = %q, want TODO", got)
+	}
+}
+
+func TestTagMatcherEmptyFilter(t *testing.T) {
+	re := tagMatcher("")
+	for _, tag := range SupportedTags {
+		if got := findTag(tag+": something", re); got != tag {
+			t.Errorf("empty -tag: findTag(%q) = %q", tag, got)
+		}
+	}
+
+	// A filter with no usable tags matches nothing
+	if got := findTag("TODO: something", tagMatcher(" , ")); got != "" {
+		t.Errorf("blank filter matched %q", got)
+	}
+}
+
+func TestTagMatcherRespectsFilter(t *testing.T) {
+	re := tagMatcher("fixme")
+	if got := findTag("TODO then FIXME", re); got != "FIXME" {
+		t.Errorf("findTag = %q, want FIXME", got)
+	}
+	if got := findTag("TODO only", re); got != "" {
+		t.Errorf("findTag = %q, want no match", got)
+	}
+}
+
+// writeLargeSource generates a Go file of n lines where every third line
+// carries a plain comment. None carry a tag: a tagged line spawns git blame,
+// whose process start-up would dwarf the parsing being measured.
+func writeLargeSource(b *testing.B, n int) string {
+	b.Helper()
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if i%3 == 0 {
+			fmt.Fprintf(&sb, "y := %d // plain explanatory comment number %d\n", i, i)
+		} else {
+			fmt.Fprintf(&sb, "z := compute(%d, %d)\n", i, i*2)
+		}
+	}
+	path := filepath.Join(b.TempDir(), "large.go")
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+func BenchmarkExtractComments(b *testing.B) {
+	path := writeLargeSource(b, 100000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cs, err := ExtractComments(path, "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(cs) != 0 {
+			b.Fatalf("found %d tagged comments; the benchmark would measure git blame", len(cs))
+		}
+	}
+}
+
+var benchTexts = []string{
+	"plain explanatory comment number 42",
+	"NOTE: keep in sync with the parser",
+	"this is a fairly long comment that mentions no tag at all, as most do",
+	"FIXME handle the error returned by Close",
+}
+
+func BenchmarkFindTag(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		re := tagMatcher("")
+		for _, text := range benchTexts {
+			findTag(text, re)
+		}
+	}
+}
+
+// BenchmarkFindTagPerCallCompile is the previous implementation, which compiled
+// one regexp per tag for every line, kept here as the baseline to compare against.
+func BenchmarkFindTagPerCallCompile(b *testing.B) {
+	tags := parseTags("")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, text := range benchTexts {
+			upper := strings.ToUpper(text)
+			for t := range tags {
+				re := regexp.MustCompile(`\b` + regexp.QuoteMeta(t) + `\b`)
+				if re.MatchString(upper) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestCommentIDSurvivesLineShifts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	ids := func(src string) []string {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cs, err := ExtractComments(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, c := range cs {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	before := ids("// TODO same\n// TODO same\n")
+	after := ids("package a\n\n// TODO same\nvar x = 1\n// TODO same\n")
+	if len(before) != 2 || before[0] == before[1] {
+		t.Fatalf("identical comments need distinct IDs, got %v", before)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("IDs changed after inserting lines: %v -> %v", before, after)
+	}
+}
+
+func TestCommentIDIgnoresRootSpelling(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "core"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "core", "a.go"), []byte("// TODO same\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relRoot, err := filepath.Rel(wd, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, r := range []string{root, relRoot, root + string(filepath.Separator)} {
+		stream, errc := ScanRoots([]string{r}, 1, "", true)
+		for res := range stream {
+			for _, c := range res.Comments {
+				ids = append(ids, c.ID)
+			}
+		}
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ids) != 3 || ids[0] != ids[1] || ids[0] != ids[2] {
+		t.Errorf("IDs differ across root spellings: %v", ids)
+	}
+}
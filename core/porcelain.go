@@ -0,0 +1,101 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// PorcelainVersion identifies the porcelain schema. Fields are only ever added
+// within a version; renaming or removing one bumps it.
+const PorcelainVersion = 1
+
+// PorcelainFormats lists the accepted values for -porcelain.
+var PorcelainFormats = []string{"quickfix", "jsonrpc"}
+
+// porcelainComment is the stable, editor-facing view of a Comment.
+// It is decoupled from Comment so internal changes never leak into plugins.
+type porcelainComment struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+	Author  string `json:"author"`
+	Commit  string `json:"commit"`
+	Stamp   string `json:"stamp"`
+	Module  string `json:"module"`
+	Root    string `json:"root"`
+}
+
+// rpcNotification is a JSON-RPC 2.0 notification (no id, no reply expected).
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// WritePorcelain writes comments in a machine-readable format for editor plugins,
+// sorted by file and line. Supported formats:
+//
+//	quickfix  one "file:line: [TAG] content" line per comment (errorformat %f:%l:\ %m)
+//	jsonrpc   one JSON-RPC 2.0 "tdl/comment" notification per line, then "tdl/done"
+func WritePorcelain(w io.Writer, m map[string][]Comment, format string) error {
+	var all []Comment
+	for _, list := range m {
+		all = append(all, list...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].FilePath != all[j].FilePath {
+			return all[i].FilePath < all[j].FilePath
+		}
+		return all[i].LineNumber < all[j].LineNumber
+	})
+
+	switch format {
+	case "quickfix":
+		for _, c := range all {
+			if _, err := fmt.Fprintf(w, "%s:%d: [%s] %s\n",
+				c.FilePath, c.LineNumber, c.Tag, c.Content); err != nil {
+				return err
+			}
+		}
+	case "jsonrpc":
+		enc := json.NewEncoder(w)
+		for _, c := range all {
+			if err := enc.Encode(rpcNotification{
+				JSONRPC: "2.0",
+				Method:  "tdl/comment",
+				Params: porcelainComment{
+					Version: PorcelainVersion,
+					ID:      c.ID,
+					File:    c.FilePath,
+					Line:    c.LineNumber,
+					Tag:     c.Tag,
+					Content: c.Content,
+					Author:  c.Author,
+					Commit:  c.Commit,
+					Stamp:   c.CreationStamp,
+					Module:  c.Module,
+					Root:    c.Root,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+		// Lets plugins know the stream is complete without waiting for EOF
+		return enc.Encode(rpcNotification{
+			JSONRPC: "2.0",
+			Method:  "tdl/done",
+			Params: map[string]int{
+				"version": PorcelainVersion,
+				"count":   len(all),
+			},
+		})
+	default:
+		return fmt.Errorf("unsupported porcelain format: %s", format)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+// porcelainFixture is out of order on purpose: output must be sorted by file and line.
+var porcelainFixture = map[string][]Comment{
+	"web/app.ts": {
+		{ID: "9e8d7c6b", Tag: "FIXME", Content: "FIXME: handle 401", FilePath: "web/app.ts", LineNumber: 7,
+			Author: "omar", Commit: "81be0d4", CreationStamp: "2024-06-02T09:30:00Z", Module: "web", Root: "."},
+	},
+	"core/parser.go": {
+		{ID: "1a2b3c4d", Tag: "TODO", Content: "TODO handle CRLF", FilePath: "core/parser.go", LineNumber: 42,
+			Author: "jane", Commit: "3f9a2c1", CreationStamp: "2024-05-01T10:00:00Z", Root: "."},
+		{ID: "0f0f0f0f", Tag: "NOTE", Content: "NOTE keep in sync", FilePath: "core/parser.go", LineNumber: 3, Root: "."},
+	},
+}
+
+// The porcelain output is a compatibility promise to editor plugins. If one of
+// these tests fails, the change must only add fields, or bump PorcelainVersion.
+func TestWritePorcelainQuickfix(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePorcelain(&buf, porcelainFixture, "quickfix"); err != nil {
+		t.Fatal(err)
+	}
+	want := "core/parser.go:3: [NOTE] NOTE keep in sync\n" +
+		"core/parser.go:42: [TODO] TODO handle CRLF\n" +
+		"web/app.ts:7: [FIXME] FIXME: handle 401\n"
+	if got := buf.String(); got != want {
+		t.Errorf("quickfix output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWritePorcelainJSONRPC(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePorcelain(&buf, porcelainFixture, "jsonrpc"); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"jsonrpc":"2.0","method":"tdl/comment","params":{"version":1,"id":"0f0f0f0f","file":"core/parser.go","line":3,"tag":"NOTE","content":"NOTE keep in sync","author":"","commit":"","stamp":"","module":"","root":"."}}
+{"jsonrpc":"2.0","method":"tdl/comment","params":{"version":1,"id":"1a2b3c4d","file":"core/parser.go","line":42,"tag":"TODO","content":"TODO handle CRLF","author":"jane","commit":"3f9a2c1","stamp":"2024-05-01T10:00:00Z","module":"","root":"."}}
+{"jsonrpc":"2.0","method":"tdl/comment","params":{"version":1,"id":"9e8d7c6b","file":"web/app.ts","line":7,"tag":"FIXME","content":"FIXME: handle 401","author":"omar","commit":"81be0d4","stamp":"2024-06-02T09:30:00Z","module":"web","root":"."}}
+{"jsonrpc":"2.0","method":"tdl/done","params":{"count":3,"version":1}}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("jsonrpc output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWritePorcelainEmptyAndUnknown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePorcelain(&buf, nil, "jsonrpc"); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"jsonrpc":"2.0","method":"tdl/done","params":{"count":0,"version":1}}` + "\n"; buf.String() != want {
+		t.Errorf("empty jsonrpc output = %q, want %q", buf.String(), want)
+	}
+	if err := WritePorcelain(&buf, nil, "xml"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
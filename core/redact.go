@@ -0,0 +1,99 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RedactKeyFile holds the per-project secret used to hash identities,
+// relative to the project root. Keep it out of anything you share.
+const RedactKeyFile = ".tdl/redact.key"
+
+// redactedPrefix marks values that have already been hashed.
+const redactedPrefix = "anon-"
+
+// LoadRedactKey reads the project's redaction key, generating and saving a
+// random one on first use so hashes stay stable across runs.
+func LoadRedactKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("invalid redaction key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// RedactComment returns a copy of c with the author replaced by a keyed hash.
+// When truncatePaths is set, the file path (taken relative to the scan root) and
+// module are cut down to their top-level directory and the scan root to its
+// base name. Already redacted authors are left as is.
+func RedactComment(c Comment, key []byte, truncatePaths bool) Comment {
+	if c.Author != "" && !strings.HasPrefix(c.Author, redactedPrefix) {
+		c.Author = hashIdentity(c.Author, key)
+	}
+	if truncatePaths {
+		c.FilePath = topLevelDir(pathUnderRoot(c.FilePath, c.Root))
+		c.Module, _, _ = strings.Cut(c.Module, "/")
+		if c.Root != "" {
+			c.Root = filepath.Base(c.Root)
+		}
+	}
+	return c
+}
+
+// RedactComments applies RedactComment to every comment and regroups them by
+// their (possibly truncated) file path.
+func RedactComments(results map[string][]Comment, key []byte, truncatePaths bool) map[string][]Comment {
+	out := make(map[string][]Comment, len(results))
+	for _, list := range results {
+		for _, c := range list {
+			r := RedactComment(c, key, truncatePaths)
+			out[r.FilePath] = append(out[r.FilePath], r)
+		}
+	}
+	return out
+}
+
+// hashIdentity maps a name or email to a short identifier using an HMAC keyed
+// with the project secret, so it cannot be reversed by hashing a list of names.
+// The same input and key always yield the same output, so per-author counts survive.
+func hashIdentity(s string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(s))))
+	return redactedPrefix + hex.EncodeToString(mac.Sum(nil))[:10]
+}
+
+// topLevelDir keeps only the first path element (e.g. "core/parser.go" -> "core").
+// Files directly under the scan root collapse to ".".
+func topLevelDir(path string) string {
+	clean := filepath.ToSlash(filepath.Clean(path))
+	clean = strings.TrimPrefix(clean, "/")
+	first, _, found := strings.Cut(clean, "/")
+	if !found {
+		return "."
+	}
+	return first
+}
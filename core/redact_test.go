@@ -0,0 +1,72 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRedactCommentIsIdempotent(t *testing.T) {
+	key := []byte("test-key")
+	c := Comment{Author: "Jane Doe", FilePath: "core/parser.go"}
+
+	once := RedactComment(c, key, false)
+	twice := RedactComment(once, key, false)
+	if once.Author == c.Author {
+		t.Fatalf("author was not redacted: %q", once.Author)
+	}
+	if twice.Author != once.Author {
+		t.Errorf("redacting twice changed author: %q -> %q", once.Author, twice.Author)
+	}
+}
+
+func TestRedactCommentDependsOnKey(t *testing.T) {
+	c := Comment{Author: "Jane Doe"}
+	a := RedactComment(c, []byte("key-a"), false)
+	b := RedactComment(c, []byte("key-b"), false)
+	if a.Author == b.Author {
+		t.Errorf("different keys produced the same hash %q", a.Author)
+	}
+	if again := RedactComment(Comment{Author: " jane doe "}, []byte("key-a"), false); again.Author != a.Author {
+		t.Errorf("same identity hashed differently: %q vs %q", again.Author, a.Author)
+	}
+}
+
+func TestRedactCommentTruncatesPaths(t *testing.T) {
+	tests := []struct {
+		file, root string
+		want       string
+	}{
+		{"/home/me/src/app/services/billing/api.go", "/home/me/src/app", "services"},
+		{"../pt/core/parser.go", "../pt", "core"},
+		{"core/parser.go", ".", "core"},
+		{"/home/me/src/app/main.go", "/home/me/src/app", "."}, // directly under the root
+		{"core/parser.go", "", "core"},                        // scans without a recorded root
+	}
+	for _, tt := range tests {
+		got := RedactComment(Comment{FilePath: tt.file, Root: tt.root}, []byte("k"), true).FilePath
+		if got != tt.want {
+			t.Errorf("file %q under root %q truncated to %q, want %q", tt.file, tt.root, got, tt.want)
+		}
+	}
+
+	c := Comment{FilePath: "/home/me/src/app/services/billing/api.go", Module: "services/billing", Root: "/home/me/src/app"}
+	r := RedactComment(c, []byte("k"), true)
+	if r.Module != "services" || r.Root != "app" {
+		t.Errorf("got module=%q root=%q", r.Module, r.Root)
+	}
+}
+
+func TestLoadRedactKeyIsStable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tdl", "redact.key")
+	first, err := LoadRedactKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadRedactKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Error("key changed between loads")
+	}
+}
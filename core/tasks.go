@@ -0,0 +1,221 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TasksDir holds one YAML file per promoted comment, relative to the project root.
+const TasksDir = ".tdl/tasks"
+
+// Task is a tracked work item created by promoting a tagged comment.
+type Task struct {
+	ID          string     `yaml:"id"`
+	Title       string     `yaml:"title"`
+	Description string     `yaml:"description"`
+	Status      string     `yaml:"status"`
+	Created     string     `yaml:"created"` // RFC3339
+	Origin      TaskOrigin `yaml:"origin"`
+}
+
+// TaskOrigin records where a task's comment lived when it was promoted.
+type TaskOrigin struct {
+	File   string `yaml:"file"`
+	Line   int    `yaml:"line"`
+	Tag    string `yaml:"tag"`
+	Commit string `yaml:"commit,omitempty"`
+	Author string `yaml:"author,omitempty"`
+	Stamp  string `yaml:"stamp,omitempty"`
+}
+
+// tagPrefix matches leading tag decoration such as "[TODO]", "TODO:" or "TODO(bob):".
+var tagPrefix = regexp.MustCompile(`^[\[(\s]*([A-Za-z]+)(\([^)]*\))?[\])\s:\-]*`)
+
+// FindComment returns the comment whose ID equals id or, like git, starts with it.
+// An ambiguous prefix is an error.
+func FindComment(all []Comment, id string) (Comment, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return Comment{}, fmt.Errorf("empty comment id")
+	}
+
+	var matches []Comment
+	for _, c := range all {
+		if c.ID == id {
+			return c, nil
+		}
+		if strings.HasPrefix(c.ID, id) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Comment{}, fmt.Errorf("no comment with id %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return Comment{}, fmt.Errorf("id %s is ambiguous (%d comments match)", id, len(matches))
+	}
+}
+
+// NewTask builds an open task from a comment. The title is the comment text
+// without its leading tag; the description keeps the full original text.
+func NewTask(c Comment, status string) Task {
+	text := commentFromTag(c)
+	title := text
+	if m := tagPrefix.FindStringSubmatch(title); m != nil && canonicalTag(strings.ToUpper(m[1])) == c.Tag {
+		if stripped := strings.TrimSpace(title[len(m[0]):]); stripped != "" {
+			title = stripped
+		}
+	}
+	return Task{
+		ID:          c.ID,
+		Title:       title,
+		Description: text,
+		Status:      status,
+		Created:     time.Now().Format(time.RFC3339),
+		Origin: TaskOrigin{
+			File:   c.FilePath,
+			Line:   c.LineNumber,
+			Tag:    c.Tag,
+			Commit: c.Commit,
+			Author: c.Author,
+			Stamp:  c.CreationStamp,
+		},
+	}
+}
+
+// commentFromTag returns c's text starting at the real comment. When the
+// delimiter also appears in a string earlier on the line
+// (`"http://host" // TODO ...`), the scanned text still holds that code, so it
+// is cut at the last delimiter before the tag.
+func commentFromTag(c Comment) string {
+	char, ok := commentDelimiter(c.FilePath)
+	upper := strings.ToUpper(c.Content)
+	if !ok || len(upper) != len(c.Content) {
+		return c.Content // offsets would not line up
+	}
+	loc := tagMatcher(c.Tag).FindStringIndex(upper)
+	if loc == nil {
+		return c.Content
+	}
+	if i := strings.LastIndex(c.Content[:loc[0]], char); i != -1 {
+		return strings.TrimSpace(c.Content[i+len(char):])
+	}
+	return c.Content
+}
+
+// SaveTask writes t to dir/<id>.yaml. Existing tasks are never overwritten.
+func SaveTask(dir string, t Task) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create tasks directory: %w", err)
+	}
+
+	path := filepath.Join(dir, t.ID+".yaml")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return "", fmt.Errorf("task %s already exists: %s", t.ID, path)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	enc := yaml.NewEncoder(f)
+	defer enc.Close()
+	if err := enc.Encode(t); err != nil {
+		return "", fmt.Errorf("failed to write task: %w", err)
+	}
+	return path, nil
+}
+
+// LoadTasks reads every task in dir, sorted by creation time.
+// A missing directory yields no tasks.
+func LoadTasks(dir string) ([]Task, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Task
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var t Task
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+		}
+		out = append(out, t)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Created < out[j].Created })
+	return out, nil
+}
+
+// CheckCommentLine verifies that the source line of c still holds the comment
+// as it was scanned, so it can safely be replaced.
+func CheckCommentLine(c Comment) error {
+	_, _, _, err := locateComment(c)
+	return err
+}
+
+// ReplaceCommentWithReference rewrites the source line of c so the comment
+// text becomes "tdl-task <id>". The line must still contain the comment as
+// it was scanned; otherwise the file changed since and nothing is written.
+func ReplaceCommentWithReference(c Comment) error {
+	lines, start, perm, err := locateComment(c)
+	if err != nil {
+		return err
+	}
+
+	// Keep indentation, delimiter and line ending; swap only the comment text
+	line := lines[c.LineNumber-1]
+	ending := line[len(strings.TrimRight(line, "\r\n")):]
+	lines[c.LineNumber-1] = line[:start] + " tdl-task " + c.ID + ending
+	return os.WriteFile(c.FilePath, []byte(strings.Join(lines, "")), perm)
+}
+
+// locateComment reads c's file and returns its lines (endings kept), the offset
+// where the comment text starts on c's line, and the file permissions.
+// Comments that follow code on their line are refused.
+func locateComment(c Comment) ([]string, int, os.FileMode, error) {
+	char, ok := commentDelimiter(c.FilePath)
+	if !ok {
+		return nil, 0, 0, fmt.Errorf("unsupported file type: %s", c.FilePath)
+	}
+
+	info, err := os.Stat(c.FilePath)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	data, err := os.ReadFile(c.FilePath)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if c.LineNumber < 1 || c.LineNumber > len(lines) {
+		return nil, 0, 0, fmt.Errorf("%s has no line %d; rescan first", c.FilePath, c.LineNumber)
+	}
+	line := lines[c.LineNumber-1]
+	pos := strings.Index(line, char)
+	if pos == -1 || strings.TrimSpace(line[pos+len(char):]) != c.Content {
+		return nil, 0, 0, fmt.Errorf("%s:%d no longer matches the scanned comment; rescan first",
+			c.FilePath, c.LineNumber)
+	}
+	// Like MigrateTags, only touch full-line comments: code before the delimiter
+	// may hide it inside a string ("http://..."), so cutting there would break the code
+	if strings.TrimSpace(line[:pos]) != "" {
+		return nil, 0, 0, fmt.Errorf("%s:%d: comment does not start the line; edit it by hand",
+			c.FilePath, c.LineNumber)
+	}
+	return lines, pos + len(char), info.Mode().Perm(), nil
+}
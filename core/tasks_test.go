@@ -0,0 +1,76 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaceCommentWithReference(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(path, []byte("package a\r\n\t// TODO(bob): wire the cache\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := Comment{ID: "abcd1234", Tag: "TODO", Content: "TODO(bob): wire the cache", FilePath: path, LineNumber: 2}
+
+	if err := CheckCommentLine(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceCommentWithReference(c); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "package a\r\n\t// tdl-task abcd1234\r\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+
+	// The line no longer matches the scan, so a second attempt must fail
+	if err := CheckCommentLine(c); err == nil {
+		t.Error("CheckCommentLine accepted a stale comment")
+	}
+}
+
+func TestReplaceCommentRefusesCodeBeforeDelimiter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	src := "package a\n\nvar u = \"http://example.com\" // TODO: read from config\n"
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := ExtractComments(path, "")
+	if err != nil || len(cs) != 1 {
+		t.Fatalf("ExtractComments = %v, %v; want one comment", cs, err)
+	}
+
+	if err := CheckCommentLine(cs[0]); err == nil {
+		t.Error("CheckCommentLine accepted a comment that follows code")
+	}
+	if err := ReplaceCommentWithReference(cs[0]); err == nil {
+		t.Error("ReplaceCommentWithReference accepted a comment that follows code")
+	}
+	if data, _ := os.ReadFile(path); string(data) != src {
+		t.Errorf("source was modified:\n%s", data)
+	}
+	if got := NewTask(cs[0], "open").Title; got != "read from config" {
+		t.Errorf("title = %q, want %q", got, "read from config")
+	}
+}
+
+func TestNewTaskStripsTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		content string
+		want    string
+	}{
+		{"TODO", "TODO(bob): wire the cache", "wire the cache"},
+		{"FIXME", "[FIXME] broken", "broken"},
+		{"TODO", "see TODO below", "see TODO below"},
+		{"TODO", `example.com" // TODO: read from config`, "read from config"},
+		{"TODO", "TODO: keep http://example.com // as is", "keep http://example.com // as is"},
+	}
+	for _, tt := range tests {
+		c := Comment{Tag: tt.tag, Content: tt.content, FilePath: "a.go"}
+		if got := NewTask(c, "open").Title; got != tt.want {
+			t.Errorf("NewTask(%q).Title = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
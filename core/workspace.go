@@ -0,0 +1,229 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Workspace describes the module boundaries detected under a scan root.
+// Modules are stored as slash-separated paths relative to Root.
+type Workspace struct {
+	Root    string
+	Modules []string
+}
+
+// quotedString matches double-quoted strings inside TOML arrays.
+var quotedString = regexp.MustCompile(`"([^"]*)"`)
+
+// DetectWorkspace looks for go.work, package.json workspaces and Cargo
+// workspace members under root and returns every module directory found.
+// A root without any workspace manifest yields a Workspace with no modules.
+func DetectWorkspace(root string) (*Workspace, error) {
+	ws := &Workspace{Root: filepath.Clean(root)}
+
+	var patterns []string
+	for _, detect := range []func(string) ([]string, error){
+		goWorkModules, npmWorkspaces, cargoMembers,
+	} {
+		found, err := detect(ws.Root)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, found...)
+	}
+
+	seen := make(map[string]struct{})
+	for _, p := range patterns {
+		// package.json and Cargo.toml members may contain globs (e.g. "packages/*")
+		matches, err := filepath.Glob(filepath.Join(ws.Root, filepath.FromSlash(p)))
+		if err != nil {
+			continue // malformed pattern, skip it
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err != nil || !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(ws.Root, m)
+			if err != nil || rel == "." {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			if _, ok := seen[rel]; ok {
+				continue
+			}
+			seen[rel] = struct{}{}
+			ws.Modules = append(ws.Modules, rel)
+		}
+	}
+
+	// Longest paths first so nested modules win over their parents
+	sort.Slice(ws.Modules, func(i, j int) bool {
+		if len(ws.Modules[i]) != len(ws.Modules[j]) {
+			return len(ws.Modules[i]) > len(ws.Modules[j])
+		}
+		return ws.Modules[i] < ws.Modules[j]
+	})
+	return ws, nil
+}
+
+// ModuleFor returns the module containing filePath, or "" if it lies outside every module.
+func (ws *Workspace) ModuleFor(filePath string) string {
+	if ws == nil || len(ws.Modules) == 0 {
+		return ""
+	}
+	rel, err := filepath.Rel(ws.Root, filepath.Clean(filePath))
+	if err != nil {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	for _, m := range ws.Modules {
+		if rel == m || strings.HasPrefix(rel, m+"/") {
+			return m
+		}
+	}
+	return ""
+}
+
+// FilterByModule keeps only comments belonging to one of the comma-separated modules.
+// An empty modules string returns results unchanged.
+func FilterByModule(results map[string][]Comment, modules string) map[string][]Comment {
+	if strings.TrimSpace(modules) == "" {
+		return results
+	}
+	want := make(map[string]struct{})
+	for _, m := range strings.Split(modules, ",") {
+		m = strings.Trim(filepath.ToSlash(strings.TrimSpace(m)), "/")
+		if m != "" {
+			want[m] = struct{}{}
+		}
+	}
+
+	out := make(map[string][]Comment)
+	for file, list := range results {
+		for _, c := range list {
+			if _, ok := want[c.Module]; ok {
+				out[file] = append(out[file], c)
+			}
+		}
+	}
+	return out
+}
+
+// goWorkModules reads `use` directives from go.work (both single and block form).
+func goWorkModules(root string) ([]string, error) {
+	f, err := os.Open(filepath.Join(root, "go.work"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []string
+	inBlock := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			out = append(out, strings.Trim(line, `"`))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			out = append(out, strings.Trim(strings.TrimSpace(line[len("use "):]), `"`))
+		}
+	}
+	return out, sc.Err()
+}
+
+// npmWorkspaces reads the "workspaces" field of package.json.
+// Both the array form and the {"packages": [...]} form are accepted.
+func npmWorkspaces(root string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil || len(pkg.Workspaces) == 0 {
+		return nil, nil // not a workspace manifest we understand
+	}
+
+	var list []string
+	if err := json.Unmarshal(pkg.Workspaces, &list); err == nil {
+		return list, nil
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(pkg.Workspaces, &obj); err == nil {
+		return obj.Packages, nil
+	}
+	return nil, nil
+}
+
+// cargoMembers reads `members` from the [workspace] table of Cargo.toml.
+func cargoMembers(root string) ([]string, error) {
+	f, err := os.Open(filepath.Join(root, "Cargo.toml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []string
+	inWorkspace, inMembers := false, false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		if inMembers {
+			for _, m := range quotedString.FindAllStringSubmatch(line, -1) {
+				out = append(out, m[1])
+			}
+			if strings.Contains(line, "]") {
+				inMembers = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inWorkspace = line == "[workspace]"
+			continue
+		}
+		if !inWorkspace || !strings.HasPrefix(line, "members") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "members" {
+			continue
+		}
+		for _, m := range quotedString.FindAllStringSubmatch(value, -1) {
+			out = append(out, m[1])
+		}
+		inMembers = !strings.Contains(value, "]")
+	}
+	return out, sc.Err()
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeTree creates files (slash-separated path -> content) and directories
+// (paths ending in "/") under root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectWorkspace(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "go.work block",
+			files: map[string]string{
+				"go.work":  "go 1.22\n\nuse (\n\t./svc/api // the API\n\t\"./lib\"\n)\n",
+				"svc/api/": "", "lib/": "",
+			},
+			want: []string{"lib", "svc/api"},
+		},
+		{
+			name: "go.work single",
+			files: map[string]string{
+				"go.work": "go 1.22\nuse ./tools\nuse ./missing\n",
+				"tools/":  "",
+			},
+			want: []string{"tools"},
+		},
+		{
+			name: "package.json globbed array",
+			files: map[string]string{
+				"package.json": `{"name":"mono","workspaces":["packages/*","apps/web"]}`,
+				"packages/a/":  "", "packages/b/": "", "packages/README.md": "x",
+				"apps/web/": "",
+			},
+			want: []string{"apps/web", "packages/a", "packages/b"},
+		},
+		{
+			name: "package.json packages object",
+			files: map[string]string{
+				"package.json": `{"workspaces":{"packages":["pkgs/*"],"nohoist":["**/x"]}}`,
+				"pkgs/ui/":     "",
+			},
+			want: []string{"pkgs/ui"},
+		},
+		{
+			name: "package.json without workspaces",
+			files: map[string]string{
+				"package.json": `{"name":"single"}`,
+			},
+			want: nil,
+		},
+		{
+			name: "Cargo.toml multi-line members",
+			files: map[string]string{
+				"Cargo.toml":   "[package]\nname = \"root\"\nmembers = [\"ignored\"]\n\n[workspace]\nmembers = [\n  \"crates/*\", # all crates\n  \"tools/gen\",\n]\n\n[dependencies]\n",
+				"crates/core/": "", "crates/cli/": "", "tools/gen/": "", "ignored/": "",
+			},
+			want: []string{"crates/cli", "crates/core", "tools/gen"},
+		},
+		{
+			name: "Cargo.toml single-line members",
+			files: map[string]string{
+				"Cargo.toml": "[workspace]\nmembers = [\"a\", \"b\"]\n",
+				"a/":         "", "b/": "",
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name:  "no manifest",
+			files: map[string]string{"main.go": "package main\n"},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			ws, err := DetectWorkspace(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := append([]string(nil), ws.Modules...)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("modules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleForPrefersNestedModule(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"go.work":        "use (\n\t./svc\n\t./svc/api\n)\n",
+		"svc/api/":       "",
+		"svc/apiclient/": "",
+	})
+	ws, err := DetectWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"svc/api/handler.go", "svc/api"},
+		{"svc/apiclient/client.go", "svc"}, // shares a prefix but is not inside svc/api
+		{"svc/main.go", "svc"},
+		{"main.go", ""},
+	}
+	for _, tt := range tests {
+		if got := ws.ModuleFor(filepath.Join(root, filepath.FromSlash(tt.path))); got != tt.want {
+			t.Errorf("ModuleFor(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if got := (*Workspace)(nil).ModuleFor("a.go"); got != "" {
+		t.Errorf("nil workspace: ModuleFor = %q", got)
+	}
+}
+
+func TestFilterByModule(t *testing.T) {
+	results := map[string][]Comment{
+		"svc/api/a.go": {{Module: "svc/api"}, {Module: "svc/api"}},
+		"web/b.ts":     {{Module: "web"}},
+		"main.go":      {{Module: ""}},
+	}
+
+	if got := FilterByModule(results, ""); !reflect.DeepEqual(got, results) {
+		t.Errorf("empty filter changed results: %v", got)
+	}
+	got := FilterByModule(results, " /svc/api/ ,web")
+	if len(got) != 2 || len(got["svc/api/a.go"]) != 2 || len(got["web/b.ts"]) != 1 {
+		t.Errorf("filter svc/api,web = %v", got)
+	}
+	if got := FilterByModule(results, "other"); len(got) != 0 {
+		t.Errorf("unknown module matched %v", got)
+	}
+}
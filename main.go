@@ -4,15 +4,21 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"syscall"
 	"tdl/core"
+	"time"
 )
 
 func main() {
 	// Basic CLI entrypoint — dispatches based on first argument
 	if len(os.Args) < 2 {
-		fmt.Println("Expected subcommand: init | destroy | scan | print")
+		fmt.Println("Expected subcommand: init | destroy | scan | print | daemon | migrate-tags | promote | tasks | badge")
 		os.Exit(1)
 	}
 
@@ -25,6 +31,16 @@ func main() {
 		scanCodeBase(os.Args[2:]) // scan project and extract tagged comments
 	case "print":
 		printComments() // read .tdl/comments.json and pretty-print
+	case "daemon":
+		runDaemon(os.Args[2:]) // keep an in-memory index and serve queries
+	case "migrate-tags":
+		migrateTags(os.Args[2:]) // normalize alias tags (XXX -> HACK) in source files
+	case "promote":
+		promoteComment(os.Args[2:]) // turn a comment into a task under .tdl/tasks
+	case "tasks":
+		listTasks() // list promoted tasks
+	case "badge":
+		makeBadge(os.Args[2:]) // render a debt-count badge for READMEs
 	default:
 		fmt.Println("Unknown command:", os.Args[1])
 		os.Exit(1)
@@ -80,11 +96,12 @@ func destroyTdl() {
 }
 
 // scanCodeBase:
-// 1. parse flags
-// 2. collect all files
-// 3. run comment extraction concurrently
-// 4. write JSON results to .tdl
-// 5. optionally pretty-print and show stats
+// 1. parse flags; roots are positional args, or -dirpath when none are given
+// 2. detect workspace modules under each root
+// 3. stream files from all roots' walkers into concurrent comment extraction
+// 4. assign modules as results arrive
+// 5. stream results into .tdl/comments.json
+// 6. optionally pretty-print and show stats
 func scanCodeBase(args []string) {
 	// setup CLI flags
 	fs := flag.NewFlagSet("scan", flag.ExitOnError)
@@ -92,59 +109,219 @@ func scanCodeBase(args []string) {
 	tag := fs.String("tag", "", "Comma-separated tags to filter by")
 	color := fs.Bool("color", true, "Enable color output")
 	printFlag := fs.Bool("print", false, "Also pretty-print after scanning")
+	redact := fs.Bool("redact", false, "With -print, hash author names before printing")
+	redactPaths := fs.Bool("redact-paths", false, "With -print, also truncate file paths to top-level directories (implies -redact)")
 	ignore := fs.Bool("ignore", true, "Skip unsupported file extensions silently")
 	workers := fs.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
 
 	// custom usage info
 	fs.Usage = func() {
-		fmt.Println("Usage: tdl scan [options]")
+		fmt.Println("Usage: tdl scan [options] [dir ...]")
 		fs.PrintDefaults()
 	}
 
 	fs.Parse(args)
 
-	// Step 1: recursively collect files under dirpath
-	files, err := core.GetAllFilePaths(*dirpath)
-	if err != nil {
-		fmt.Println("Error scanning directory:", err)
+	// Step 1: load tag aliases and find workspace modules (monorepos) under every root
+	if err := loadTagAliases(); err != nil {
+		fmt.Println("Error loading config:", err)
 		return
 	}
+	roots := uniqueRoots(fs.Args())
+	if len(roots) == 0 {
+		roots = []string{*dirpath}
+	}
+	workspaces := make(map[string]*core.Workspace, len(roots))
+	for _, root := range roots {
+		ws, err := core.DetectWorkspace(root)
+		if err != nil {
+			fmt.Println("Error detecting workspace:", err)
+			return
+		}
+		workspaces[root] = ws
+	}
 
-	// Step 2: run extraction using multiple goroutines
-	results := core.RunExtractCommentsConcurrently(files, *workers, *tag, *ignore)
-
-	// Step 3: ensure .tdl exists before writing
+	// Step 2: open the output before scanning so results can stream into it
 	if err := os.MkdirAll(".tdl", 0755); err != nil {
 		fmt.Println("Failed to create .tdl directory:", err)
 		return
 	}
+	w, err := core.NewCommentWriter("json", ".tdl")
+	if err != nil {
+		fmt.Println("Error writing output:", err)
+		return
+	}
+
+	// Step 3: one walker per root feeds paths straight into the shared worker pool
+	stream, walkErr := core.ScanRoots(roots, *workers, *tag, *ignore)
+
+	// Step 4: consume results as they arrive; keep them only if we must print
+	results := make(map[string][]core.Comment)
+	scanned := 0
+	var writeErr error
+	for r := range stream {
+		scanned++
+		if len(r.Comments) == 0 || writeErr != nil {
+			continue // keep draining so workers and walker can finish
+		}
+		module := workspaces[r.Root].ModuleFor(r.Path)
+		for i := range r.Comments {
+			r.Comments[i].Module = module
+		}
+		writeErr = w.Write(r.Comments)
+		if *printFlag {
+			for _, c := range r.Comments {
+				results[c.FilePath] = append(results[c.FilePath], c)
+			}
+		}
+	}
+	if err := <-walkErr; err != nil {
+		w.Discard()
+		fmt.Println("Error scanning directory:", err)
+		return
+	}
+	if writeErr != nil {
+		w.Discard()
+		fmt.Println("Error writing output:", writeErr)
+		return
+	}
 
-	// Step 4: save comments to JSON
-	if err := core.PrepareOutputFile(results, "json", ".tdl"); err != nil {
+	// Step 5: move the finished JSON into place
+	totalComments := w.Count()
+	if err := w.Close(); err != nil {
 		fmt.Println("Error writing output:", err)
 		return
 	}
 
-	// Step 5: optional pretty-print after scan
+	// Step 6: optional pretty-print after scan; redaction never touches the saved JSON
 	if *printFlag {
-		core.PrettyPrintComments(results, *color)
+		if *redact || *redactPaths {
+			key, err := core.LoadRedactKey(core.RedactKeyFile)
+			if err != nil {
+				fmt.Println("Error loading redaction key:", err)
+				return
+			}
+			results = core.RedactComments(results, key, *redactPaths)
+		}
+		core.PrettyPrintComments(results, core.PrintOptions{Color: *color})
 	}
 
 	// Show quick stats
-	totalComments := 0
-	for _, cs := range results {
-		totalComments += len(cs)
+	if len(roots) > 1 {
+		fmt.Printf("Scanned %d files across %d roots, found %d comments.\n", scanned, len(roots), totalComments)
+		return
 	}
-	fmt.Printf("Scanned %d files, found %d comments.\n", len(files), totalComments)
+	fmt.Printf("Scanned %d files, found %d comments.\n", scanned, totalComments)
 }
 
-// printComments loads .tdl/comments.json and prints with optional coloring
+// uniqueRoots cleans the given directories and drops duplicates (including
+// different spellings of the same directory, like "." and its absolute path),
+// keeping the first spelling and the original order.
+func uniqueRoots(dirs []string) []string {
+	seen := make(map[string]struct{}, len(dirs))
+	var out []string
+	for _, d := range dirs {
+		d = filepath.Clean(d)
+		key := d
+		if abs, err := filepath.Abs(d); err == nil {
+			key = abs
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, d)
+	}
+	return out
+}
+
+// printComments loads comments (daemon or .tdl/comments.json) and prints with optional coloring
 func printComments() {
+	// parse optional flags for print
+	fs := flag.NewFlagSet("print", flag.ExitOnError)
+	color := fs.Bool("color", true, "Enable colorized output")
+	module := fs.String("module", "", "Comma-separated workspace modules to filter by")
+	redact := fs.Bool("redact", false, "Hash author names before printing")
+	redactPaths := fs.Bool("redact-paths", false, "Also truncate file paths to top-level directories (implies -redact)")
+	porcelain := fs.String("porcelain", "", "Machine-readable output for editors: "+strings.Join(core.PorcelainFormats, " | "))
+	showID := fs.Bool("id", false, "Show comment IDs (for tdl promote)")
+	author := fs.Bool("author", false, "Show the author of each comment")
+	age := fs.Bool("age", false, "Show how old each comment is")
+	commit := fs.Bool("commit", false, "Show the short commit hash of each comment")
+	staleDays := fs.Int("stale-days", 365, "Highlight comments older than this many days in color output (0 disables)")
+	useDaemon := fs.Bool("daemon", false, "Read the live index of a running tdl daemon instead of .tdl/comments.json")
+	fs.Parse(os.Args[2:])
+
+	all, err := loadComments(*useDaemon)
+	if err != nil {
+		if *porcelain != "" {
+			// plugins read stdout; keep it clean and signal failure
+			fmt.Fprintln(os.Stderr, "Error loading comments:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Error loading comments:", err)
+		return
+	}
+
+	// regroup by file for PrettyPrintComments
+	results := make(map[string][]core.Comment)
+	for _, c := range all {
+		results[c.FilePath] = append(results[c.FilePath], c)
+	}
+
+	results = core.FilterByModule(results, *module)
+	// redaction only ever applies to output, never to the stored index
+	if *redact || *redactPaths {
+		key, err := core.LoadRedactKey(core.RedactKeyFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading redaction key:", err)
+			os.Exit(1)
+		}
+		results = core.RedactComments(results, key, *redactPaths)
+	}
+
+	// editor plugins get a stable format instead of the human one
+	if *porcelain != "" {
+		if err := core.WritePorcelain(os.Stdout, results, *porcelain); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing porcelain output:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// pretty print the comments
+	core.PrettyPrintComments(results, core.PrintOptions{
+		Color:      *color,
+		ShowID:     *showID,
+		ShowAuthor: *author,
+		ShowAge:    *age,
+		ShowCommit: *commit,
+		StaleAfter: time.Duration(*staleDays) * 24 * time.Hour,
+	})
+}
+
+// loadComments reads the last scan saved in .tdl/comments.json or, when
+// useDaemon is set, the live index of a running daemon. The daemon may index
+// a different directory or tag set than the last scan, so it is only used on
+// request and the source is reported on stderr.
+func loadComments(useDaemon bool) ([]core.Comment, error) {
+	if useDaemon {
+		resp, err := core.QueryDaemon(core.DaemonSocket)
+		if err != nil {
+			return nil, fmt.Errorf("no daemon answering on %s: %w", core.DaemonSocket, err)
+		}
+		tags := resp.Tags
+		if tags == "" {
+			tags = "all"
+		}
+		fmt.Fprintf(os.Stderr, "Using daemon index (root %s, tags %s)\n", resp.Root, tags)
+		return resp.Comments, nil
+	}
+
 	filePath := ".tdl/comments.json"
 	f, err := os.Open(filePath)
 	if err != nil {
-		fmt.Println("Error opening comments file:", err)
-		return
+		return nil, err
 	}
 	defer f.Close()
 
@@ -152,22 +329,322 @@ func printComments() {
 	var all []core.Comment
 	dec := json.NewDecoder(f)
 	if err := dec.Decode(&all); err != nil {
-		fmt.Println("Error decoding JSON:", err)
+		return nil, fmt.Errorf("decoding %s: %w", filePath, err)
+	}
+	return all, nil
+}
+
+// runDaemon:
+// 1. build the in-memory index for dirpath
+// 2. listen on .tdl/daemon.sock
+// 3. poll the filesystem and re-parse changed files
+// 4. serve queries until interrupted, then remove the socket
+func runDaemon(args []string) {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	dirpath := fs.String("dirpath", ".", "Directory to index and watch")
+	tag := fs.String("tag", "", "Comma-separated tags to filter by")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+	interval := fs.Duration("interval", 2*time.Second, "How often to check for changed files")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: tdl daemon [options]")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	// Step 1: refuse to start twice, clean up a stale socket otherwise
+	if core.DaemonRunning(core.DaemonSocket) {
+		fmt.Println("Daemon already running on", core.DaemonSocket)
 		return
 	}
+	os.Remove(core.DaemonSocket)
 
-	// regroup by file for PrettyPrintComments
-	results := make(map[string][]core.Comment)
-	for _, c := range all {
-		results[c.FilePath] = append(results[c.FilePath], c)
+	if err := loadTagAliases(); err != nil {
+		fmt.Println("Error loading config:", err)
+		return
 	}
+	idx, err := core.NewIndex(*dirpath, *tag, *workers)
+	if err != nil {
+		fmt.Println("Error detecting workspace:", err)
+		return
+	}
+	if _, err := idx.Refresh(); err != nil {
+		fmt.Println("Error scanning directory:", err)
+		return
+	}
+	files, comments := idx.Stats()
+	fmt.Printf("Indexed %d files, found %d comments.\n", files, comments)
 
-	// parse optional flags for print
-	fs := flag.NewFlagSet("print", flag.ExitOnError)
-	color := fs.Bool("color", true, "Enable colorized output")
-	fs.Parse(os.Args[2:])
+	// Step 2: listen for queries
+	if err := os.MkdirAll(".tdl", 0755); err != nil {
+		fmt.Println("Failed to create .tdl directory:", err)
+		return
+	}
+	ln, err := net.Listen("unix", core.DaemonSocket)
+	if err != nil {
+		fmt.Println("Error listening:", err)
+		return
+	}
+	defer os.Remove(core.DaemonSocket)
 
-	// pretty print the comments
-	core.PrettyPrintComments(results, *color)
+	// Step 3: keep the index warm
+	stop := make(chan struct{})
+	go idx.Watch(*interval, stop)
+
+	// Step 4: shut down cleanly on Ctrl-C / SIGTERM
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		close(stop)
+		ln.Close()
+	}()
+
+	fmt.Println("Listening on", core.DaemonSocket)
+	if err := core.ServeIndex(ln, idx); err != nil {
+		fmt.Println("Error serving:", err)
+	}
 }
 
+// loadTagAliases registers the tag aliases from .tdl/config.yaml, if any.
+func loadTagAliases() error {
+	cfg, err := core.LoadConfig(core.ConfigFile)
+	if err != nil {
+		return err
+	}
+	core.SetTagAliases(cfg.Aliases)
+	return nil
+}
+
+// migrateTags rewrites alias tags to their canonical tag in source comments.
+// Aliases come from -from/-to or, if those are omitted, from .tdl/config.yaml.
+// Without -write it only reports what would change.
+func migrateTags(args []string) {
+	fs := flag.NewFlagSet("migrate-tags", flag.ExitOnError)
+	dirpath := fs.String("dirpath", ".", "Directory to recursively migrate")
+	from := fs.String("from", "", "Comma-separated alias tags to replace (e.g. XXX,TBD)")
+	to := fs.String("to", "", "Canonical tag to replace them with (e.g. TODO)")
+	write := fs.Bool("write", false, "Rewrite files in place instead of a dry run")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: tdl migrate-tags [options]")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	// Step 1: resolve which aliases to migrate
+	aliases := make(map[string]string)
+	switch {
+	case *from != "" && *to != "":
+		for _, alias := range strings.Split(*from, ",") {
+			aliases[alias] = *to
+		}
+	case *from != "" || *to != "":
+		fmt.Println("Both -from and -to are required")
+		return
+	default:
+		cfg, err := core.LoadConfig(core.ConfigFile)
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		aliases = cfg.Aliases
+	}
+	if len(aliases) == 0 {
+		fmt.Println("No aliases to migrate: pass -from/-to or add aliases to", core.ConfigFile)
+		return
+	}
+
+	// Step 2: rewrite (or preview) every supported file
+	files, err := core.GetAllFilePaths(*dirpath)
+	if err != nil {
+		fmt.Println("Error scanning directory:", err)
+		return
+	}
+	total, touched, skipped := 0, 0, 0
+	for _, file := range files {
+		rewrites, err := core.MigrateTags(file, aliases, *write)
+		if err != nil {
+			fmt.Printf("Error processing %s: %v\n", file, err)
+			continue
+		}
+		fileChanged := false
+		for _, r := range rewrites {
+			note := ""
+			if r.Skipped {
+				// the delimiter may sit inside code or a string literal
+				note = " (skipped: comment does not start the line, edit by hand)"
+				skipped++
+			} else {
+				total++
+				fileChanged = true
+			}
+			fmt.Printf("%s:%d%s\n    - %s\n    + %s\n", r.FilePath, r.LineNumber, note,
+				strings.TrimSpace(r.Before), strings.TrimSpace(r.After))
+		}
+		if fileChanged {
+			touched++
+		}
+	}
+
+	if *write {
+		fmt.Printf("Rewrote %d lines in %d files", total, touched)
+	} else {
+		fmt.Printf("Would rewrite %d lines in %d files", total, touched)
+	}
+	if skipped > 0 {
+		fmt.Printf(", skipped %d", skipped)
+	}
+	if !*write {
+		fmt.Print(" (use -write to apply)")
+	}
+	fmt.Println(".")
+}
+
+// promoteComment moves a comment into a task file under .tdl/tasks and
+// optionally replaces the comment in the source with a reference to it.
+func promoteComment(args []string) {
+	fs := flag.NewFlagSet("promote", flag.ExitOnError)
+	replace := fs.Bool("replace", false, "Replace the source comment with a tdl-task reference")
+	status := fs.String("status", "open", "Initial task status")
+	useDaemon := fs.Bool("daemon", false, "Resolve the id against a running tdl daemon")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: tdl promote [options] <id>")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	// Step 1: resolve the id (prefixes allowed) against the latest scan
+	all, err := loadComments(*useDaemon)
+	if err != nil {
+		fmt.Println("Error loading comments:", err)
+		return
+	}
+	c, err := core.FindComment(all, fs.Arg(0))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	// Step 2: make sure the source can be rewritten before creating anything
+	if *replace {
+		if err := core.CheckCommentLine(c); err != nil {
+			fmt.Println("Error replacing comment:", err)
+			return
+		}
+	}
+
+	// Step 3: write the task file
+	task := core.NewTask(c, *status)
+	path, err := core.SaveTask(core.TasksDir, task)
+	if err != nil {
+		fmt.Println("Error saving task:", err)
+		return
+	}
+
+	// Step 4: optionally swap the comment for a reference; undo the task if that fails
+	if *replace {
+		if err := core.ReplaceCommentWithReference(c); err != nil {
+			os.Remove(path)
+			fmt.Println("Error replacing comment:", err)
+			return
+		}
+	}
+	fmt.Printf("Promoted %s:%d to %s\n", c.FilePath, c.LineNumber, path)
+	if *replace {
+		fmt.Printf("Replaced comment with reference tdl-task %s\n", c.ID)
+	}
+}
+
+// listTasks prints every task in .tdl/tasks.
+func listTasks() {
+	tasks, err := core.LoadTasks(core.TasksDir)
+	if err != nil {
+		fmt.Println("Error loading tasks:", err)
+		return
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No tasks. Promote a comment with: tdl promote <id>")
+		return
+	}
+	for _, t := range tasks {
+		fmt.Printf("%-8s  %-8s  %s  (%s:%d)\n", t.ID, t.Status, t.Title, t.Origin.File, t.Origin.Line)
+	}
+}
+
+// makeBadge scans dirpath and renders the comment count as an SVG badge or as
+// a shields.io endpoint JSON file. It scans afresh instead of reading
+// .tdl/comments.json, so the count never depends on an earlier scan's -tag or
+// directory. Any failure exits non-zero so CI jobs notice.
+func makeBadge(args []string) {
+	fs := flag.NewFlagSet("badge", flag.ExitOnError)
+	dirpath := fs.String("dirpath", ".", "Directory to recursively scan")
+	tag := fs.String("tag", "", "Comma-separated tags to count (default: all)")
+	label := fs.String("label", "", "Badge label (default: derived from -tag)")
+	format := fs.String("format", "svg", "Output format: svg | json (shields.io endpoint)")
+	output := fs.String("output", "", "Output file (default: badge.svg or badge.json)")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: tdl badge [options]")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	fail := func(msg string, err error) {
+		fmt.Fprintln(os.Stderr, msg, err)
+		os.Exit(1)
+	}
+
+	if err := loadTagAliases(); err != nil {
+		fail("Error loading config:", err)
+	}
+	if *format != "svg" && *format != "json" {
+		fail("Unsupported badge format:", fmt.Errorf("%s", *format))
+	}
+
+	// Count from a fresh scan; blame metadata is never shown, so skip it
+	stream, walkErr := core.ScanRootsWithoutBlame([]string{*dirpath}, *workers, *tag, true)
+	var all []core.Comment
+	for r := range stream {
+		all = append(all, r.Comments...)
+	}
+	if err := <-walkErr; err != nil {
+		fail("Error scanning directory:", err)
+	}
+	count := core.CountTagged(all, *tag)
+
+	if *label == "" {
+		*label = "tech debt"
+		if *tag != "" {
+			*label = strings.ToLower(strings.ReplaceAll(*tag, ",", "/")) + "s"
+		}
+	}
+
+	var data []byte
+	if *format == "svg" {
+		data = core.RenderBadgeSVG(*label, count)
+	} else {
+		var err error
+		if data, err = core.MarshalShieldsEndpoint(*label, count); err != nil {
+			fail("Error encoding badge:", err)
+		}
+	}
+
+	if *output == "" {
+		*output = "badge." + *format
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fail("Error writing badge:", err)
+	}
+	fmt.Printf("Badge written to %s (%s: %d)\n", *output, *label, count)
+}